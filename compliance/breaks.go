@@ -0,0 +1,82 @@
+// Package compliance checks recorded shifts against statutory break rules.
+// The default rules follow the Korean Labor Standards Act (Article 54): at
+// least 30 minutes of break for 4 hours of work and 1 hour for 8 hours.
+package compliance
+
+import "fmt"
+
+// BreakRule requires RequiredBreakMinutes of break once a shift has
+// MinWorkMinutes of work
+type BreakRule struct {
+	MinWorkMinutes       int `json:"min_work_minutes"`
+	RequiredBreakMinutes int `json:"required_break_minutes"`
+}
+
+// DefaultBreakRules are the statutory minimums under Korean labor law
+var DefaultBreakRules = []BreakRule{
+	{MinWorkMinutes: 4 * 60, RequiredBreakMinutes: 30},
+	{MinWorkMinutes: 8 * 60, RequiredBreakMinutes: 60},
+}
+
+// Violation is a shift that did not get the break a rule requires
+type Violation struct {
+	Rule           BreakRule `json:"rule"`
+	WorkedMinutes  int       `json:"worked_minutes"`
+	BreakMinutes   int       `json:"break_minutes"`
+	ShortByMinutes int       `json:"short_by_minutes"`
+	Message        string    `json:"message"`
+}
+
+// applicableRule is the strictest rule a number of minutes reaches, which
+// subsumes the lighter ones, or nil
+func applicableRule(rules []BreakRule, minutes int) *BreakRule {
+	var applicable *BreakRule
+	for i, rule := range rules {
+		if minutes < rule.MinWorkMinutes {
+			continue
+		}
+		if applicable == nil || rule.MinWorkMinutes > applicable.MinWorkMinutes {
+			applicable = &rules[i]
+		}
+	}
+	return applicable
+}
+
+// ScheduledBreakMinutes is the break a scheduled shift of spanMinutes must
+// include, which is unpaid and so not part of the contracted hours. Rules
+// are matched on the work left once their own break is taken out, the way
+// CheckBreaks sees the shift: a 4h slot is 3.5h of work and needs no break.
+func ScheduledBreakMinutes(rules []BreakRule, spanMinutes int) int {
+	var applicable *BreakRule
+	for i, rule := range rules {
+		if spanMinutes-rule.RequiredBreakMinutes < rule.MinWorkMinutes {
+			continue
+		}
+		if applicable == nil || rule.MinWorkMinutes > applicable.MinWorkMinutes {
+			applicable = &rules[i]
+		}
+	}
+	if applicable == nil {
+		return 0
+	}
+	return applicable.RequiredBreakMinutes
+}
+
+// CheckBreaks evaluates one shift's work and break minutes against the rules.
+// Only the strictest rule the shift reaches is applied. It returns nil when
+// the shift complies.
+func CheckBreaks(rules []BreakRule, workedMinutes, breakMinutes int) *Violation {
+	applicable := applicableRule(rules, workedMinutes)
+	if applicable == nil || breakMinutes >= applicable.RequiredBreakMinutes {
+		return nil
+	}
+
+	return &Violation{
+		Rule:           *applicable,
+		WorkedMinutes:  workedMinutes,
+		BreakMinutes:   breakMinutes,
+		ShortByMinutes: applicable.RequiredBreakMinutes - breakMinutes,
+		Message: fmt.Sprintf("%d minutes of work require a %d minute break, got %d",
+			workedMinutes, applicable.RequiredBreakMinutes, breakMinutes),
+	}
+}
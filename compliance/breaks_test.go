@@ -0,0 +1,52 @@
+package compliance
+
+import "testing"
+
+func TestScheduledBreakMinutes(t *testing.T) {
+	tests := []struct {
+		span int
+		want int
+	}{
+		{0, 0},
+		{240, 0}, // 4h with a 30 minute break would be 3.5h of work
+		{269, 0},
+		{270, 30}, // 4:30 leaves exactly 4h of work
+		{480, 30},
+		{510, 30}, // 8:30 is 7.5h of work with the hour break
+		{539, 30},
+		{540, 60}, // 9h leaves exactly 8h of work
+		{600, 60},
+	}
+
+	for _, tt := range tests {
+		if got := ScheduledBreakMinutes(DefaultBreakRules, tt.span); got != tt.want {
+			t.Errorf("ScheduledBreakMinutes(%d) = %d, want %d", tt.span, got, tt.want)
+		}
+	}
+	if got := ScheduledBreakMinutes(nil, 600); got != 0 {
+		t.Errorf("ScheduledBreakMinutes without rules = %d, want 0", got)
+	}
+}
+
+func TestCheckBreaks(t *testing.T) {
+	tests := []struct {
+		worked, breaks int
+		wantShortBy    int // 0 means compliant
+	}{
+		{200, 0, 0},
+		{240, 30, 0},
+		{240, 20, 10},
+		{480, 30, 30},
+		{480, 60, 0},
+	}
+
+	for _, tt := range tests {
+		v := CheckBreaks(DefaultBreakRules, tt.worked, tt.breaks)
+		switch {
+		case tt.wantShortBy == 0 && v != nil:
+			t.Errorf("CheckBreaks(%d, %d) = %+v, want compliant", tt.worked, tt.breaks, v)
+		case tt.wantShortBy != 0 && (v == nil || v.ShortByMinutes != tt.wantShortBy):
+			t.Errorf("CheckBreaks(%d, %d) = %+v, want short by %d", tt.worked, tt.breaks, v, tt.wantShortBy)
+		}
+	}
+}
@@ -22,7 +22,7 @@ func AdminLogin(c *gin.Context) {
 	var admin models.Employee
 	// Ensure OTP is compared as a string in the database
 	if err := initializers.DB.
-		Where("CAST(otp AS TEXT) = ? AND role = ?", body.OTP, "admin").
+		Where("CAST(otp AS TEXT) = ? AND role = ? AND active = ?", body.OTP, "admin", true).
 		First(&admin).Error; err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid OTP or not an admin"})
 		return
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -8,6 +9,7 @@ import (
 	"github.com/aoncodev/qrbackend/initializers"
 	"github.com/aoncodev/qrbackend/models"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // UpdateAttendance updates an attendance record
@@ -36,6 +38,8 @@ func UpdateAttendance(c *gin.Context) {
 		return
 	}
 
+	before := attendance
+
 	// Update fields if provided
 	if req.ClockIn != nil {
 		attendance.ClockIn = *req.ClockIn
@@ -44,7 +48,14 @@ func UpdateAttendance(c *gin.Context) {
 		attendance.ClockOut = req.ClockOut
 	}
 
-	if err := initializers.DB.Save(&attendance).Error; err != nil {
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&attendance).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "attendance.update", "attendance", attendance.ID, before, attendance)
+	})
+
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update attendance"})
 		return
 	}
@@ -83,29 +94,62 @@ func UpdateAttendanceBreaks(c *gin.Context) {
 		return
 	}
 
-	// Delete existing breaks
-	if err := initializers.DB.Where("attendance_id = ?", id).Delete(&models.BreakLog{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete existing breaks"})
-		return
-	}
-
-	// Create new breaks
+	// Build the replacement breaks
 	var breaks []models.BreakLog
+	var codes []string
 	for _, b := range req.Breaks {
+		codes = append(codes, b.BreakType)
 		breakLog := models.BreakLog{
 			AttendanceID: uint(id),
-			BreakType:    b.BreakType,
+			BreakType:    normalizeBreakType(b.BreakType),
 			BreakStart:   b.Start,
 			BreakEnd:     b.End,
 		}
 		breaks = append(breaks, breakLog)
 	}
 
-	if len(breaks) > 0 {
-		if err := initializers.DB.Create(&breaks).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create breaks"})
-			return
+	// Replace existing breaks atomically
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var before []models.BreakLog
+		if err := tx.Where("attendance_id = ?", id).Order("break_start").Find(&before).Error; err != nil {
+			return err
 		}
+
+		// Breaks already on the shift may keep a since deactivated type
+		catalog, err := loadBreakCatalog(tx)
+		if err != nil {
+			return err
+		}
+		var existing []string
+		for _, b := range before {
+			existing = append(existing, b.BreakType)
+		}
+		if err := catalog.CheckShift(existing, codes); err != nil {
+			return err
+		}
+		if err := tx.Where("attendance_id = ?", id).Delete(&models.BreakLog{}).Error; err != nil {
+			return err
+		}
+		if len(breaks) > 0 {
+			if err := tx.Create(&breaks).Error; err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx, auditActor(c), "attendance.breaks.replace", "attendance", uint(id), before, breaks)
+	})
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only one break per attendance record can be open"})
+		return
+	}
+	var ce *clockError
+	if errors.As(err, &ce) {
+		c.JSON(ce.Status, gin.H{"error": ce.Message})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update breaks"})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -142,12 +186,34 @@ func AddBreak(c *gin.Context) {
 
 	breakLog := models.BreakLog{
 		AttendanceID: uint(id),
-		BreakType:    req.BreakType,
+		BreakType:    normalizeBreakType(req.BreakType),
 		BreakStart:   req.Start,
 		BreakEnd:     req.End,
 	}
 
-	if err := initializers.DB.Create(&breakLog).Error; err != nil {
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockAttendance(tx, uint(id)); err != nil {
+			return err
+		}
+		if err := checkShiftBreakTx(tx, uint(id), breakLog.BreakType); err != nil {
+			return err
+		}
+		if err := tx.Create(&breakLog).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "break.create", "break", breakLog.ID, nil, breakLog)
+	})
+
+	var ce *clockError
+	if errors.As(err, &ce) {
+		c.JSON(ce.Status, gin.H{"error": ce.Message})
+		return
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			c.JSON(http.StatusConflict, gin.H{"error": "This attendance record already has an open break"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create break"})
 		return
 	}
@@ -183,7 +249,21 @@ func DeleteBreak(c *gin.Context) {
 	}
 
 	// Delete the break
-	if err := initializers.DB.Where("id = ? AND attendance_id = ?", breakIDUint, attID).Delete(&models.BreakLog{}).Error; err != nil {
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var breakLog models.BreakLog
+		if err := tx.Where("id = ? AND attendance_id = ?", breakIDUint, attID).First(&breakLog).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil // nothing to delete
+			}
+			return err
+		}
+		if err := tx.Delete(&breakLog).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "break.delete", "break", breakLog.ID, breakLog, nil)
+	})
+
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete break"})
 		return
 	}
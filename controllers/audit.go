@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// auditActor returns the signed-in user from the JWT, or nil outside a request
+func auditActor(c *gin.Context) *uint {
+	if c == nil {
+		return nil
+	}
+	if _, ok := c.Get("userID"); !ok {
+		return nil
+	}
+	id := c.GetUint("userID")
+	return &id
+}
+
+// recordAudit appends an audit entry inside tx so it commits with the change.
+// before/after are snapshots of the entity and may be nil.
+func recordAudit(tx *gorm.DB, actorID *uint, action, entityType string, entityID uint, before, after interface{}) error {
+	entry := models.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+	}
+
+	if before != nil {
+		data, err := json.Marshal(before)
+		if err != nil {
+			return err
+		}
+		entry.Before = data
+	}
+	if after != nil {
+		data, err := json.Marshal(after)
+		if err != nil {
+			return err
+		}
+		entry.After = data
+	}
+
+	return tx.Create(&entry).Error
+}
+
+// GetAuditLogs lists audit entries, newest first, filtered by entity_type,
+// entity_id, actor_id, action and a start_date/end_date business day range
+func GetAuditLogs(c *gin.Context) {
+	query := initializers.DB.Order("created_at DESC, id DESC")
+
+	if entityType := c.Query("entity_type"); entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if entityID := c.Query("entity_id"); entityID != "" {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if actorID := c.Query("actor_id"); actorID != "" {
+		query = query.Where("actor_id = ?", actorID)
+	}
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if startDate := c.Query("start_date"); startDate != "" {
+		start, err := utils.ParseBusinessDate(startDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start_date format, use YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("created_at >= ?", start)
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		end, err := utils.ParseBusinessDate(endDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end_date format, use YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("created_at < ?", end.AddDate(0, 0, 1))
+	}
+
+	limit := 200
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 1000 {
+			limit = n
+		}
+	}
+
+	var entries []models.AuditLog
+	if err := query.Limit(limit).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit logs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"audit_logs": entries})
+}
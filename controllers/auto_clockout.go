@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// autoClockOutAfter is how long a shift without a scheduled end may stay open
+// before the job closes it, from AUTO_CLOCK_OUT_AFTER (Go duration, default 16h)
+func autoClockOutAfter() time.Duration {
+	after, err := time.ParseDuration(os.Getenv("AUTO_CLOCK_OUT_AFTER"))
+	if err != nil || after <= 0 {
+		return 16 * time.Hour
+	}
+	return after
+}
+
+// autoClockOutGrace is how long after the scheduled end a shift may stay
+// open, from AUTO_CLOCK_OUT_GRACE (Go duration, default 2h)
+func autoClockOutGrace() time.Duration {
+	grace, err := time.ParseDuration(os.Getenv("AUTO_CLOCK_OUT_GRACE"))
+	if err != nil || grace < 0 {
+		return 2 * time.Hour
+	}
+	return grace
+}
+
+// autoClockOutAt is the clock-out time recorded for a forgotten shift: the
+// scheduled end of its business day plus the grace period, or the fixed
+// window after clock-in when there is no scheduled end or the shift started
+// after it
+func autoClockOutAt(attendance models.AttendanceLog, schedule employeeSchedule) time.Time {
+	if end, ok := schedule.On(attendance.BusinessDate).End(attendance.BusinessDate); ok {
+		if cutoff := end.Add(autoClockOutGrace()); cutoff.After(attendance.ClockIn) {
+			return cutoff
+		}
+	}
+	return attendance.ClockIn.Add(autoClockOutAfter())
+}
+
+// AutoClockOutShifts closes every shift whose cutoff has passed, ending its
+// breaks no later than the cutoff, and flags them for admin review. A shift
+// that cannot be closed is logged and skipped so it does not hold up the
+// rest. It returns how many shifts were closed.
+func AutoClockOutShifts(now time.Time) (int, error) {
+	var candidates []models.AttendanceLog
+	if err := initializers.DB.Where("clock_out IS NULL").Order("id").Find(&candidates).Error; err != nil {
+		return 0, err
+	}
+
+	closed := 0
+	for _, candidate := range candidates {
+		var employee models.Employee
+		if err := initializers.DB.First(&employee, candidate.EmployeeID).Error; err != nil {
+			log.Printf("auto clock-out of attendance %d skipped, employee %d not loaded: %v", candidate.ID, candidate.EmployeeID, err)
+			continue
+		}
+		schedule, err := loadEmployeeSchedule(initializers.DB, employee, candidate.BusinessDate, candidate.BusinessDate)
+		if err != nil {
+			log.Printf("auto clock-out of attendance %d skipped, schedule not loaded: %v", candidate.ID, err)
+			continue
+		}
+
+		cutoff := autoClockOutAt(candidate, schedule)
+		if cutoff.After(now) {
+			continue
+		}
+
+		err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+			attendance, err := lockAttendance(tx, candidate.ID)
+			if err != nil {
+				return err
+			}
+			if attendance.ClockOut != nil {
+				return nil // clocked out since we looked
+			}
+
+			// End open breaks, and breaks recorded past the cutoff, at the
+			// cutoff, or at their own start if they began later
+			if err := tx.Model(&models.BreakLog{}).
+				Where("attendance_id = ? AND (break_end IS NULL OR break_end > ?)", attendance.ID, cutoff).
+				Update("break_end", gorm.Expr("GREATEST(break_start, ?)", cutoff)).Error; err != nil {
+				return err
+			}
+
+			before := *attendance
+			attendance.ClockOut = &cutoff
+			attendance.AutoClosed = true
+			if err := tx.Save(attendance).Error; err != nil {
+				return err
+			}
+			if err := recordAudit(tx, nil, "attendance.auto_clock_out", "attendance", attendance.ID, before, attendance); err != nil {
+				return err
+			}
+			closed++
+			return nil
+		})
+		if err != nil {
+			log.Printf("auto clock-out of attendance %d failed: %v", candidate.ID, err)
+		}
+	}
+
+	return closed, nil
+}
+
+// RunAutoClockOut runs AutoClockOutShifts, and deactivates employees past
+// their termination date, every interval. Run it in a goroutine.
+func RunAutoClockOut(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		closed, err := AutoClockOutShifts(time.Now())
+		if err != nil {
+			log.Printf("auto clock-out failed: %v", err)
+		}
+		if closed > 0 {
+			log.Printf("auto clock-out closed %d forgotten shifts", closed)
+		}
+
+		deactivated, err := DeactivateTerminatedEmployees(time.Now())
+		if err != nil {
+			log.Printf("deactivating terminated employees failed: %v", err)
+		}
+		if deactivated > 0 {
+			log.Printf("deactivated %d employees past their termination date", deactivated)
+		}
+	}
+}
+
+// GetAutoClosedAttendance lists shifts closed by the auto clock-out job.
+// Pass reviewed=true to see ones already reviewed; the default is pending only.
+func GetAutoClosedAttendance(c *gin.Context) {
+	reviewed, _ := strconv.ParseBool(c.DefaultQuery("reviewed", "false"))
+
+	query := initializers.DB.Where("auto_closed = ?", true)
+	if reviewed {
+		query = query.Where("reviewed_at IS NOT NULL")
+	} else {
+		query = query.Where("reviewed_at IS NULL")
+	}
+
+	var logs []models.AttendanceLog
+	if err := query.Order("clock_in DESC").Preload("Breaks").Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch auto-closed attendance"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attendance": logs})
+}
+
+// ReviewAutoClosedAttendance marks an auto-closed shift as reviewed by the
+// current admin. Corrections are made through UpdateAttendance first.
+func ReviewAutoClosedAttendance(c *gin.Context) {
+	attendanceID := c.Param("attendance_id")
+	id, err := strconv.ParseUint(attendanceID, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attendance ID"})
+		return
+	}
+
+	var attendance models.AttendanceLog
+	if err := initializers.DB.First(&attendance, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance record not found"})
+		return
+	}
+	if !attendance.AutoClosed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Attendance record was not auto-closed"})
+		return
+	}
+
+	before := attendance
+	now := time.Now()
+	adminID := c.GetUint("userID")
+	attendance.ReviewedAt = &now
+	attendance.ReviewedBy = &adminID
+
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&attendance).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "attendance.review", "attendance", attendance.ID, before, attendance)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review attendance"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Attendance marked as reviewed",
+		"attendance": attendance,
+	})
+}
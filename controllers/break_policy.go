@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/notify"
+	"github.com/gin-gonic/gin"
+)
+
+// breakCheck is a break measured against its type's maximum duration
+type breakCheck struct {
+	AllowedMinutes int        `json:"allowed_minutes"` // 0 = no limit
+	DueAt          *time.Time `json:"due_at"`
+	Overdue        bool       `json:"overdue"`
+	OverdueMinutes int        `json:"overdue_minutes"`
+}
+
+// Check evaluates a break against its limit; open breaks are measured up to now
+func (c breakCatalog) Check(b models.BreakLog, now time.Time) breakCheck {
+	breakType, ok := c[b.BreakType]
+	if !ok || breakType.MaxMinutes <= 0 {
+		return breakCheck{}
+	}
+
+	due := b.BreakStart.Add(time.Duration(breakType.MaxMinutes) * time.Minute)
+	check := breakCheck{AllowedMinutes: breakType.MaxMinutes, DueAt: &due}
+
+	end := now
+	if b.BreakEnd != nil {
+		end = *b.BreakEnd
+	}
+	if end.After(due) {
+		check.Overdue = true
+		check.OverdueMinutes = int(end.Sub(due).Minutes())
+	}
+	return check
+}
+
+// overdueBreak is an open break past its allowed duration
+type overdueBreak struct {
+	Break      models.BreakLog
+	Attendance models.AttendanceLog
+	Check      breakCheck
+}
+
+// findOverdueBreaks returns the open breaks that have run past their limit
+func findOverdueBreaks(now time.Time, onlyUnnotified bool) ([]overdueBreak, error) {
+	catalog, err := loadBreakCatalog(initializers.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	query := initializers.DB.Where("break_end IS NULL")
+	if onlyUnnotified {
+		query = query.Where("overrun_notified_at IS NULL")
+	}
+	var open []models.BreakLog
+	if err := query.Order("break_start").Find(&open).Error; err != nil {
+		return nil, err
+	}
+
+	overdue := []overdueBreak{}
+	var attendanceIDs []uint
+	for _, b := range open {
+		check := catalog.Check(b, now)
+		if !check.Overdue {
+			continue
+		}
+		overdue = append(overdue, overdueBreak{Break: b, Check: check})
+		attendanceIDs = append(attendanceIDs, b.AttendanceID)
+	}
+	if len(overdue) == 0 {
+		return overdue, nil
+	}
+
+	// Load the shifts of all overdue breaks at once
+	var attendances []models.AttendanceLog
+	if err := initializers.DB.Where("id IN ?", attendanceIDs).Find(&attendances).Error; err != nil {
+		return nil, err
+	}
+	attendanceByID := map[uint]models.AttendanceLog{}
+	for _, a := range attendances {
+		attendanceByID[a.ID] = a
+	}
+	for i := range overdue {
+		overdue[i].Attendance = attendanceByID[overdue[i].Break.AttendanceID]
+	}
+	return overdue, nil
+}
+
+// NotifyBreakOverruns sends one break.overrun event per open break that has
+// passed its allowed duration. It returns how many alerts were sent.
+func NotifyBreakOverruns(now time.Time) (int, error) {
+	overdue, err := findOverdueBreaks(now, true)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, o := range overdue {
+		// Leave the alert unclaimed for the next tick if the employee can't be loaded
+		var employee models.Employee
+		if err := initializers.DB.First(&employee, o.Attendance.EmployeeID).Error; err != nil {
+			log.Printf("break overrun notification for break %d skipped, employee %d not loaded: %v", o.Break.ID, o.Attendance.EmployeeID, err)
+			continue
+		}
+
+		// Claim the alert so a second instance or a later tick doesn't repeat it
+		result := initializers.DB.Model(&models.BreakLog{}).
+			Where("id = ? AND break_end IS NULL AND overrun_notified_at IS NULL", o.Break.ID).
+			Update("overrun_notified_at", now)
+		if result.Error != nil {
+			return sent, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		event := notify.Event{
+			Type:       "break.overrun",
+			EmployeeID: o.Attendance.EmployeeID,
+			Message: fmt.Sprintf("%s's %s break is %d minutes over its %d minute limit",
+				employee.Name, o.Break.BreakType, o.Check.OverdueMinutes, o.Check.AllowedMinutes),
+			OccurredAt: now,
+			Data: map[string]interface{}{
+				"attendance_id":   o.Attendance.ID,
+				"break_id":        o.Break.ID,
+				"break_type":      o.Break.BreakType,
+				"break_start":     o.Break.BreakStart,
+				"due_at":          o.Check.DueAt,
+				"overdue_minutes": o.Check.OverdueMinutes,
+			},
+		}
+		if err := initializers.Notifier.Notify(event); err != nil {
+			log.Printf("break overrun notification for break %d failed: %v", o.Break.ID, err)
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// RunBreakOverrunMonitor runs NotifyBreakOverruns every interval. Run it in a goroutine.
+func RunBreakOverrunMonitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := NotifyBreakOverruns(time.Now()); err != nil {
+			log.Printf("break overrun check failed: %v", err)
+		}
+	}
+}
+
+// GetOverdueBreaks lists the breaks currently running past their limit
+func GetOverdueBreaks(c *gin.Context) {
+	overdue, err := findOverdueBreaks(time.Now(), false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch overdue breaks"})
+		return
+	}
+
+	var employees []models.Employee
+	if err := initializers.DB.Find(&employees).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch employees"})
+		return
+	}
+	names := map[uint]string{}
+	for _, e := range employees {
+		names[e.ID] = e.Name
+	}
+
+	results := []gin.H{}
+	for _, o := range overdue {
+		results = append(results, gin.H{
+			"employee_id":         o.Attendance.EmployeeID,
+			"employee":            names[o.Attendance.EmployeeID],
+			"attendance_id":       o.Attendance.ID,
+			"break":               o.Break,
+			"allowed_minutes":     o.Check.AllowedMinutes,
+			"due_at":              o.Check.DueAt,
+			"overdue_minutes":     o.Check.OverdueMinutes,
+			"overrun_notified_at": o.Break.OverrunNotifiedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"breaks": results})
+}
@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// normalizeBreakType folds client input onto catalog codes, so "Lunch " and
+// "lunch" are the same type
+func normalizeBreakType(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+// breakCatalog maps break type codes to their catalog entry, inactive ones
+// included so historical breaks can still be priced
+type breakCatalog map[string]models.BreakType
+
+func loadBreakCatalog(db *gorm.DB) (breakCatalog, error) {
+	var types []models.BreakType
+	if err := db.Find(&types).Error; err != nil {
+		return nil, err
+	}
+	catalog := breakCatalog{}
+	for _, t := range types {
+		catalog[t.Code] = t
+	}
+	return catalog, nil
+}
+
+// Allow returns the catalog entry for a new break, rejecting unknown and
+// deactivated types
+func (c breakCatalog) Allow(code string) (models.BreakType, error) {
+	breakType, ok := c[normalizeBreakType(code)]
+	if !ok || !breakType.Active {
+		return breakType, rejectClock(http.StatusBadRequest, fmt.Sprintf("Unknown break type %q", code))
+	}
+	return breakType, nil
+}
+
+// CheckShift validates the break types of all breaks on one shift, including
+// the per-shift count limits. existing are the types already recorded on the
+// shift: those breaks are kept as they are even if their type has since been
+// deactivated or its limit lowered, so only added breaks must pass.
+func (c breakCatalog) CheckShift(existing, codes []string) error {
+	recorded := map[string]int{}
+	for _, code := range existing {
+		recorded[normalizeBreakType(code)]++
+	}
+
+	counts := map[string]int{}
+	for _, code := range codes {
+		normalized := normalizeBreakType(code)
+		counts[normalized]++
+		if counts[normalized] <= recorded[normalized] {
+			continue
+		}
+
+		breakType, err := c.Allow(code)
+		if err != nil {
+			return err
+		}
+		if breakType.MaxPerShift > 0 && counts[normalized] > breakType.MaxPerShift {
+			return rejectClock(http.StatusBadRequest, fmt.Sprintf("At most %d %s break(s) are allowed per shift", breakType.MaxPerShift, breakType.Name))
+		}
+	}
+	return nil
+}
+
+// UnpaidMinutes is how much of a break is deducted from pay: all of an unpaid
+// break, and the part of a paid break beyond its maximum duration. Breaks of
+// types missing from the catalog are treated as unpaid.
+func (c breakCatalog) UnpaidMinutes(code string, minutes int) int {
+	breakType, ok := c[code]
+	if !ok || !breakType.Paid {
+		return minutes
+	}
+	if breakType.MaxMinutes > 0 && minutes > breakType.MaxMinutes {
+		return minutes - breakType.MaxMinutes
+	}
+	return 0
+}
+
+// checkShiftBreakTx validates adding one more break of the given type to a
+// shift. Must run inside a transaction that has locked the shift.
+func checkShiftBreakTx(tx *gorm.DB, attendanceID uint, code string) error {
+	catalog, err := loadBreakCatalog(tx)
+	if err != nil {
+		return err
+	}
+	codes, err := shiftBreakTypes(tx, attendanceID)
+	if err != nil {
+		return err
+	}
+	return catalog.CheckShift(codes, append(codes, code))
+}
+
+// shiftBreakTypes lists the break types recorded on a shift
+func shiftBreakTypes(db *gorm.DB, attendanceID uint) ([]string, error) {
+	var codes []string
+	if err := db.Model(&models.BreakLog{}).Where("attendance_id = ?", attendanceID).Pluck("break_type", &codes).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// GetBreakTypes lists the active break types; include_inactive=true lists all
+func GetBreakTypes(c *gin.Context) {
+	query := initializers.DB.Order("name")
+	if c.Query("include_inactive") != "true" {
+		query = query.Where("active = ?", true)
+	}
+
+	var types []models.BreakType
+	if err := query.Find(&types).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch break types"})
+		return
+	}
+
+	c.JSON(http.StatusOK, types)
+}
+
+type breakTypeInput struct {
+	Name        string `json:"name" binding:"required"`
+	Paid        bool   `json:"paid"`
+	MaxMinutes  int    `json:"max_minutes"`
+	MaxPerShift int    `json:"max_per_shift"`
+}
+
+func (in breakTypeInput) validate() string {
+	if in.MaxMinutes < 0 || in.MaxPerShift < 0 {
+		return "max_minutes and max_per_shift must not be negative"
+	}
+	return ""
+}
+
+// CreateBreakType adds a break type to the catalog
+func CreateBreakType(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+		breakTypeInput
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code and name are required"})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	breakType := models.BreakType{
+		Code:        normalizeBreakType(req.Code),
+		Name:        req.Name,
+		Paid:        req.Paid,
+		MaxMinutes:  req.MaxMinutes,
+		MaxPerShift: req.MaxPerShift,
+		Active:      true,
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&breakType).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "break_type.create", "break_type", breakType.ID, nil, breakType)
+	})
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A break type with this code already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create break type"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, breakType)
+}
+
+// UpdateBreakType changes a break type's name, pay flag and limits. The code
+// is fixed because recorded breaks refer to it.
+func UpdateBreakType(c *gin.Context) {
+	var breakType models.BreakType
+	if err := initializers.DB.First(&breakType, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Break type not found"})
+		return
+	}
+
+	var req struct {
+		breakTypeInput
+		Active *bool `json:"active"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	before := breakType
+	breakType.Name = req.Name
+	breakType.Paid = req.Paid
+	breakType.MaxMinutes = req.MaxMinutes
+	breakType.MaxPerShift = req.MaxPerShift
+	if req.Active != nil {
+		breakType.Active = *req.Active
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&breakType).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "break_type.update", "break_type", breakType.ID, before, breakType)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update break type"})
+		return
+	}
+
+	c.JSON(http.StatusOK, breakType)
+}
+
+// DeactivateBreakType stops a break type from being used for new breaks.
+// Recorded breaks keep it so reports still price them.
+func DeactivateBreakType(c *gin.Context) {
+	var breakType models.BreakType
+	if err := initializers.DB.First(&breakType, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Break type not found"})
+		return
+	}
+
+	before := breakType
+	breakType.Active = false
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&breakType).Update("active", false).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "break_type.deactivate", "break_type", breakType.ID, before, breakType)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deactivate break type"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Break type deactivated"})
+}
@@ -0,0 +1,327 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/shiftstate"
+	"github.com/aoncodev/qrbackend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// clockError is a rejected clock/break action with the status to report
+type clockError struct {
+	Status  int
+	Message string
+}
+
+func (e *clockError) Error() string { return e.Message }
+
+func rejectClock(status int, message string) error {
+	return &clockError{Status: status, Message: message}
+}
+
+// respondClockError writes the response for an error returned from a clock
+// transaction. Unique index violations mean a concurrent request won the race.
+func respondClockError(c *gin.Context, err error, fallback string) {
+	var ce *clockError
+	switch {
+	case errors.As(err, &ce):
+		c.JSON(ce.Status, gin.H{"error": ce.Message})
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		c.JSON(http.StatusConflict, gin.H{"error": "Conflicting request already in progress, please retry"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// lockEmployee takes a row lock on the employee so clock actions for the
+// same person run one at a time
+func lockEmployee(tx *gorm.DB, employeeID uint) (*models.Employee, error) {
+	var employee models.Employee
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&employee, employeeID).Error; err != nil {
+		return nil, rejectClock(http.StatusNotFound, "Employee not found")
+	}
+	return &employee, nil
+}
+
+// overlappingShift returns a shift of the employee, other than excludeID,
+// that overlaps clockIn to clockOut, or nil. A nil clockOut is a shift that
+// is still open.
+func overlappingShift(tx *gorm.DB, employeeID, excludeID uint, clockIn time.Time, clockOut *time.Time) (*models.AttendanceLog, error) {
+	query := tx.Where("employee_id = ? AND id <> ? AND (clock_out IS NULL OR clock_out > ?)", employeeID, excludeID, clockIn)
+	if clockOut != nil {
+		query = query.Where("clock_in < ?", *clockOut)
+	}
+
+	var other models.AttendanceLog
+	err := query.Order("clock_in").First(&other).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &other, nil
+}
+
+// lockAttendance loads and row locks an attendance log
+func lockAttendance(tx *gorm.DB, attendanceID uint) (*models.AttendanceLog, error) {
+	var attendance models.AttendanceLog
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&attendance, attendanceID).Error; err != nil {
+		return nil, rejectClock(http.StatusNotFound, "Attendance log not found")
+	}
+	return &attendance, nil
+}
+
+// checkNotBeforeShiftEvents rejects an event timed before the shift's
+// clock-in or before the end of its last break, which only happens when
+// queued offline events arrive out of order
+func checkNotBeforeShiftEvents(tx *gorm.DB, attendance *models.AttendanceLog, at time.Time) error {
+	if at.Before(attendance.ClockIn) {
+		return rejectClock(http.StatusConflict, "Event is earlier than the clock-in")
+	}
+
+	var lastBreakEnd sql.NullTime
+	if err := tx.Model(&models.BreakLog{}).
+		Where("attendance_id = ?", attendance.ID).
+		Select("MAX(break_end)").
+		Row().Scan(&lastBreakEnd); err != nil {
+		return err
+	}
+	if lastBreakEnd.Valid && at.Before(lastBreakEnd.Time) {
+		return rejectClock(http.StatusConflict, "Event is earlier than the end of the previous break")
+	}
+	return nil
+}
+
+// shiftState is an employee's state plus the open shift and break behind it
+type shiftState struct {
+	State shiftstate.State
+	Shift *models.AttendanceLog // open shift, if any
+	Break *models.BreakLog      // open break on the open shift, if any
+}
+
+// loadEmployeeState derives the employee's state on the business day of `at`
+func loadEmployeeState(db *gorm.DB, employeeID uint, at time.Time) (shiftState, error) {
+	var snapshot shiftstate.Snapshot
+	var result shiftState
+
+	var shift models.AttendanceLog
+	err := db.
+		Where("employee_id = ? AND clock_out IS NULL", employeeID).
+		Order("created_at DESC").
+		First(&shift).Error
+	switch {
+	case err == nil:
+		snapshot.ShiftOpen = true
+		snapshot.HasShiftToday = true
+		result.Shift = &shift
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		var shiftsToday int64
+		if err := db.Model(&models.AttendanceLog{}).
+			Where("employee_id = ? AND business_date = ?", employeeID, utils.BusinessDate(at)).
+			Count(&shiftsToday).Error; err != nil {
+			return result, err
+		}
+		snapshot.HasShiftToday = shiftsToday > 0
+	default:
+		return result, err
+	}
+
+	if result.Shift != nil {
+		if err := loadOpenBreak(db, result.Shift.ID, &result, &snapshot); err != nil {
+			return result, err
+		}
+	}
+
+	result.State = shiftstate.Of(snapshot)
+	return result, nil
+}
+
+// loadAttendanceState derives the state of one specific shift
+func loadAttendanceState(db *gorm.DB, attendance *models.AttendanceLog) (shiftState, error) {
+	snapshot := shiftstate.Snapshot{HasShiftToday: true, ShiftOpen: attendance.ClockOut == nil}
+	result := shiftState{}
+
+	if snapshot.ShiftOpen {
+		result.Shift = attendance
+		if err := loadOpenBreak(db, attendance.ID, &result, &snapshot); err != nil {
+			return result, err
+		}
+	}
+
+	result.State = shiftstate.Of(snapshot)
+	return result, nil
+}
+
+func loadOpenBreak(db *gorm.DB, attendanceID uint, result *shiftState, snapshot *shiftstate.Snapshot) error {
+	var breakLog models.BreakLog
+	err := db.
+		Where("attendance_id = ? AND break_end IS NULL", attendanceID).
+		Order("created_at DESC").
+		First(&breakLog).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	snapshot.BreakOpen = true
+	result.Break = &breakLog
+	return nil
+}
+
+// checkTransition rejects actions the state machine does not allow
+func checkTransition(state shiftState, action shiftstate.Action) error {
+	if _, err := shiftstate.Transition(state.State, action); err != nil {
+		return rejectClock(http.StatusBadRequest, err.Error())
+	}
+	return nil
+}
+
+// clockInTx opens a new shift at the given time. Must run inside a transaction.
+func clockInTx(tx *gorm.DB, employeeID uint, at time.Time) (*models.AttendanceLog, error) {
+	employee, err := lockEmployee(tx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if !employee.Active {
+		return nil, rejectClock(http.StatusForbidden, "Employee is deactivated")
+	}
+
+	state, err := loadEmployeeState(tx, employeeID, at)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTransition(state, shiftstate.ClockIn); err != nil {
+		return nil, err
+	}
+
+	// Enforce the shifts-per-day policy for the business day
+	if maxShifts := utils.MaxShiftsPerDay(); maxShifts > 0 {
+		var shiftsToday int64
+		if err := tx.Model(&models.AttendanceLog{}).
+			Where("employee_id = ? AND business_date = ?", employeeID, utils.BusinessDate(at)).
+			Count(&shiftsToday).Error; err != nil {
+			return nil, err
+		}
+		if shiftsToday >= int64(maxShifts) {
+			return nil, rejectClock(http.StatusBadRequest, "You have already clocked in today")
+		}
+	}
+
+	// Backdated (synced) clock-ins must not overlap the previous shift
+	var lastClockOut sql.NullTime
+	if err := tx.Model(&models.AttendanceLog{}).
+		Where("employee_id = ?", employeeID).
+		Select("MAX(clock_out)").
+		Row().Scan(&lastClockOut); err != nil {
+		return nil, err
+	}
+	if lastClockOut.Valid && at.Before(lastClockOut.Time) {
+		return nil, rejectClock(http.StatusConflict, "Clock-in is earlier than the previous clock-out")
+	}
+
+	attendance := models.AttendanceLog{
+		EmployeeID: employeeID,
+		ClockIn:    at,
+	}
+	if err := tx.Create(&attendance).Error; err != nil {
+		return nil, err
+	}
+	return &attendance, nil
+}
+
+// clockOutTx closes the employee's open shift. Must run inside a transaction.
+func clockOutTx(tx *gorm.DB, employeeID uint, at time.Time) (*models.AttendanceLog, error) {
+	if _, err := lockEmployee(tx, employeeID); err != nil {
+		return nil, err
+	}
+
+	state, err := loadEmployeeState(tx, employeeID, at)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTransition(state, shiftstate.ClockOut); err != nil {
+		return nil, err
+	}
+
+	attendance := state.Shift
+	if err := checkNotBeforeShiftEvents(tx, attendance, at); err != nil {
+		return nil, err
+	}
+
+	attendance.ClockOut = &at
+	if err := tx.Save(attendance).Error; err != nil {
+		return nil, err
+	}
+	return attendance, nil
+}
+
+// startBreakTx opens a break on a shift. Must run inside a transaction.
+func startBreakTx(tx *gorm.DB, attendanceID uint, breakType string, at time.Time) (*models.BreakLog, error) {
+	attendance, err := lockAttendance(tx, attendanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := loadAttendanceState(tx, attendance)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTransition(state, shiftstate.StartBreak); err != nil {
+		return nil, err
+	}
+
+	if err := checkNotBeforeShiftEvents(tx, attendance, at); err != nil {
+		return nil, err
+	}
+
+	breakType = normalizeBreakType(breakType)
+	if err := checkShiftBreakTx(tx, attendanceID, breakType); err != nil {
+		return nil, err
+	}
+
+	breakLog := models.BreakLog{
+		AttendanceID: attendanceID,
+		BreakType:    breakType,
+		BreakStart:   at,
+	}
+	if err := tx.Create(&breakLog).Error; err != nil {
+		return nil, err
+	}
+	return &breakLog, nil
+}
+
+// endBreakTx closes the open break on a shift. Must run inside a transaction.
+func endBreakTx(tx *gorm.DB, attendanceID uint, at time.Time) (*models.BreakLog, error) {
+	attendance, err := lockAttendance(tx, attendanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := loadAttendanceState(tx, attendance)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTransition(state, shiftstate.EndBreak); err != nil {
+		return nil, err
+	}
+
+	breakLog := state.Break
+	if at.Before(breakLog.BreakStart) {
+		return nil, rejectClock(http.StatusConflict, "Break end is earlier than its start")
+	}
+
+	breakLog.BreakEnd = &at
+	if err := tx.Save(breakLog).Error; err != nil {
+		return nil, err
+	}
+	return breakLog, nil
+}
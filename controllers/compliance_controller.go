@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/aoncodev/qrbackend/compliance"
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// loadBreakRules returns the configured statutory break rules. The migrator
+// seeds the Korean defaults; an empty table turns the checks off.
+func loadBreakRules(db *gorm.DB) ([]compliance.BreakRule, error) {
+	var rows []models.BreakRule
+	if err := db.Order("min_work_minutes").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	rules := make([]compliance.BreakRule, 0, len(rows))
+	for _, r := range rows {
+		rules = append(rules, compliance.BreakRule{MinWorkMinutes: r.MinWorkMinutes, RequiredBreakMinutes: r.RequiredBreakMinutes})
+	}
+	return rules, nil
+}
+
+// checkShiftCompliance evaluates one completed shift on its actual times.
+// Every break counts towards the requirement, paid or not.
+func checkShiftCompliance(rules []compliance.BreakRule, shift models.AttendanceLog) *compliance.Violation {
+	if shift.ClockOut == nil {
+		return nil
+	}
+	breaks := 0
+	for _, b := range shift.Breaks {
+		breaks += breakMinutes(b, *shift.ClockOut)
+	}
+	worked := int(shift.ClockOut.Sub(shift.ClockIn).Minutes()) - breaks
+	if worked < 0 {
+		worked = 0
+	}
+	return compliance.CheckBreaks(rules, worked, breaks)
+}
+
+// GetBreakRules lists the statutory break rules in effect
+func GetBreakRules(c *gin.Context) {
+	var rules []models.BreakRule
+	if err := initializers.DB.Order("min_work_minutes").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch break rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// SetBreakRules replaces the statutory break rules
+func SetBreakRules(c *gin.Context) {
+	var req struct {
+		Rules []compliance.BreakRule `json:"rules"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	seen := map[int]bool{}
+	var rules []models.BreakRule
+	for _, r := range req.Rules {
+		if r.MinWorkMinutes <= 0 || r.RequiredBreakMinutes <= 0 || seen[r.MinWorkMinutes] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "each rule needs positive min_work_minutes and required_break_minutes, with min_work_minutes unique"})
+			return
+		}
+		seen[r.MinWorkMinutes] = true
+		rules = append(rules, models.BreakRule{MinWorkMinutes: r.MinWorkMinutes, RequiredBreakMinutes: r.RequiredBreakMinutes})
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var before []models.BreakRule
+		if err := tx.Order("min_work_minutes").Find(&before).Error; err != nil {
+			return err
+		}
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.BreakRule{}).Error; err != nil {
+			return err
+		}
+		if len(rules) > 0 {
+			if err := tx.Create(&rules).Error; err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx, auditActor(c), "break_rules.replace", "break_rule", 0, before, rules)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update break rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// GetComplianceReport lists completed shifts between start_date and end_date
+// that did not get the statutory break, optionally for one employee
+func GetComplianceReport(c *gin.Context) {
+	startDate := c.Query("start_date")
+	endDate := c.Query("end_date")
+	if _, err := utils.ParseBusinessDate(startDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date is required (YYYY-MM-DD)"})
+		return
+	}
+	if _, err := utils.ParseBusinessDate(endDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date is required (YYYY-MM-DD)"})
+		return
+	}
+
+	rules, err := loadBreakRules(initializers.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load break rules"})
+		return
+	}
+
+	query := initializers.DB.
+		Where("business_date BETWEEN ? AND ? AND clock_out IS NOT NULL", startDate, endDate).
+		Order("business_date, clock_in").
+		Preload("Breaks")
+	if employeeID := c.Query("employee_id"); employeeID != "" {
+		query = query.Where("employee_id = ?", employeeID)
+	}
+
+	var shifts []models.AttendanceLog
+	if err := query.Find(&shifts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load attendance logs"})
+		return
+	}
+
+	var employees []models.Employee
+	if err := initializers.DB.Find(&employees).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch employees"})
+		return
+	}
+	names := map[uint]string{}
+	for _, e := range employees {
+		names[e.ID] = e.Name
+	}
+
+	violations := []gin.H{}
+	perEmployee := map[uint]int{}
+	for _, shift := range shifts {
+		violation := checkShiftCompliance(rules, shift)
+		if violation == nil {
+			continue
+		}
+		perEmployee[shift.EmployeeID]++
+		violations = append(violations, gin.H{
+			"employee_id":   shift.EmployeeID,
+			"employee":      names[shift.EmployeeID],
+			"attendance_id": shift.ID,
+			"date":          shift.BusinessDate,
+			"clock_in":      utils.InBusinessTime(shift.ClockIn),
+			"clock_out":     utils.InBusinessTime(*shift.ClockOut),
+			"violation":     violation,
+		})
+	}
+
+	employeeIDs := make([]uint, 0, len(perEmployee))
+	for id := range perEmployee {
+		employeeIDs = append(employeeIDs, id)
+	}
+	sort.Slice(employeeIDs, func(i, j int) bool { return employeeIDs[i] < employeeIDs[j] })
+	byEmployee := []gin.H{}
+	for _, id := range employeeIDs {
+		byEmployee = append(byEmployee, gin.H{
+			"employee_id": id,
+			"employee":    names[id],
+			"violations":  perEmployee[id],
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"start_date":     startDate,
+		"end_date":       endDate,
+		"rules":          rules,
+		"shifts_checked": len(shifts),
+		"violations":     violations,
+		"by_employee":    byEmployee,
+	})
+}
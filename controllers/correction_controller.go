@@ -0,0 +1,367 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// validateProposedTimes checks the proposed shift and breaks are in order
+func validateProposedTimes(clockIn time.Time, clockOut *time.Time, breaks models.ProposedBreaks) string {
+	if clockOut != nil && !clockOut.After(clockIn) {
+		return "clock_out must be after clock_in"
+	}
+	for _, b := range breaks {
+		if b.BreakType == "" {
+			return "break_type is required for every break"
+		}
+		if b.Start.Before(clockIn) || (clockOut != nil && b.Start.After(*clockOut)) {
+			return "breaks must fall within the shift"
+		}
+		if b.End != nil && (!b.End.After(b.Start) || (clockOut != nil && b.End.After(*clockOut))) {
+			return "break end must be after its start and within the shift"
+		}
+	}
+	return ""
+}
+
+func proposedBreakTypes(breaks models.ProposedBreaks) []string {
+	codes := make([]string, 0, len(breaks))
+	for _, b := range breaks {
+		codes = append(codes, b.BreakType)
+	}
+	return codes
+}
+
+// CreateCorrectionRequest lets the signed-in employee propose a fix to one of
+// their attendance records, or a missing record when attendance_id is omitted
+func CreateCorrectionRequest(c *gin.Context) {
+	var req struct {
+		AttendanceID *uint                 `json:"attendance_id"`
+		ClockIn      *time.Time            `json:"clock_in"`
+		ClockOut     *time.Time            `json:"clock_out"`
+		Breaks       models.ProposedBreaks `json:"breaks"`
+		Reason       string                `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+		return
+	}
+
+	employeeID := c.GetUint("userID")
+
+	// The times the proposal will be checked against once merged with the record
+	var clockIn time.Time
+	clockOut := req.ClockOut
+	if req.AttendanceID != nil {
+		var attendance models.AttendanceLog
+		if err := initializers.DB.Where("id = ? AND employee_id = ?", *req.AttendanceID, employeeID).First(&attendance).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attendance record not found"})
+			return
+		}
+		if req.ClockIn == nil && req.ClockOut == nil && req.Breaks == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to correct"})
+			return
+		}
+
+		clockIn = attendance.ClockIn
+		if req.ClockIn != nil {
+			clockIn = *req.ClockIn
+		}
+		if clockOut == nil {
+			clockOut = attendance.ClockOut
+		}
+	} else {
+		if req.ClockIn == nil || req.ClockOut == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "clock_in and clock_out are required for a missing shift"})
+			return
+		}
+		clockIn = *req.ClockIn
+	}
+
+	if msg := validateProposedTimes(clockIn, clockOut, req.Breaks); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if req.Breaks != nil {
+		catalog, err := loadBreakCatalog(initializers.DB)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load break types"})
+			return
+		}
+		var existing []string
+		if req.AttendanceID != nil {
+			if existing, err = shiftBreakTypes(initializers.DB, *req.AttendanceID); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load breaks"})
+				return
+			}
+		}
+		if err := catalog.CheckShift(existing, proposedBreakTypes(req.Breaks)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	correction := models.CorrectionRequest{
+		EmployeeID:       employeeID,
+		AttendanceID:     req.AttendanceID,
+		ProposedClockIn:  req.ClockIn,
+		ProposedClockOut: req.ClockOut,
+		ProposedBreaks:   req.Breaks,
+		Reason:           req.Reason,
+		Status:           "pending",
+	}
+
+	if err := initializers.DB.Create(&correction).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit correction request"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, correction)
+}
+
+// GetMyCorrectionRequests lists the signed-in employee's correction requests
+func GetMyCorrectionRequests(c *gin.Context) {
+	var corrections []models.CorrectionRequest
+	if err := initializers.DB.
+		Where("employee_id = ?", c.GetUint("userID")).
+		Order("created_at DESC").
+		Find(&corrections).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch correction requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"corrections": corrections})
+}
+
+// GetCorrectionRequests lists correction requests for admins, pending by default
+func GetCorrectionRequests(c *gin.Context) {
+	query := initializers.DB.Order("created_at")
+	if status := c.DefaultQuery("status", "pending"); status != "all" {
+		query = query.Where("status = ?", status)
+	}
+	if employeeID := c.Query("employee_id"); employeeID != "" {
+		query = query.Where("employee_id = ?", employeeID)
+	}
+
+	var corrections []models.CorrectionRequest
+	if err := query.Find(&corrections).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch correction requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"corrections": corrections})
+}
+
+// lockPendingCorrection loads and row locks a correction that is still pending
+func lockPendingCorrection(tx *gorm.DB, id uint64) (*models.CorrectionRequest, error) {
+	var correction models.CorrectionRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&correction, id).Error; err != nil {
+		return nil, rejectClock(http.StatusNotFound, "Correction request not found")
+	}
+	if correction.Status != "pending" {
+		return nil, rejectClock(http.StatusConflict, "Correction request was already "+correction.Status)
+	}
+	return &correction, nil
+}
+
+// attendanceSnapshot locks a shift and loads it with its breaks for the audit trail
+func attendanceSnapshot(tx *gorm.DB, attendanceID uint) (*models.AttendanceLog, error) {
+	attendance, err := lockAttendance(tx, attendanceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Where("attendance_id = ?", attendanceID).Order("break_start").Find(&attendance.Breaks).Error; err != nil {
+		return nil, err
+	}
+	return attendance, nil
+}
+
+// rejectOverlappingShift refuses a corrected or new shift that would overlap
+// another shift of the same employee
+func rejectOverlappingShift(tx *gorm.DB, attendance models.AttendanceLog) error {
+	other, err := overlappingShift(tx, attendance.EmployeeID, attendance.ID, attendance.ClockIn, attendance.ClockOut)
+	if err != nil {
+		return err
+	}
+	if other != nil {
+		return rejectClock(http.StatusConflict, fmt.Sprintf("Correction no longer applies: the shift overlaps shift %d", other.ID))
+	}
+	return nil
+}
+
+// applyCorrection writes an approved correction to the attendance records
+func applyCorrection(tx *gorm.DB, correction *models.CorrectionRequest) (*models.AttendanceLog, error) {
+	// Serialise with the employee's clock actions so the overlap check holds
+	if _, err := lockEmployee(tx, correction.EmployeeID); err != nil {
+		return nil, err
+	}
+
+	var attendance models.AttendanceLog
+	if correction.AttendanceID != nil {
+		locked, err := lockAttendance(tx, *correction.AttendanceID)
+		if err != nil {
+			return nil, err
+		}
+		attendance = *locked
+		if correction.ProposedClockIn != nil {
+			attendance.ClockIn = *correction.ProposedClockIn
+		}
+		if correction.ProposedClockOut != nil {
+			attendance.ClockOut = correction.ProposedClockOut
+		}
+		if msg := validateProposedTimes(attendance.ClockIn, attendance.ClockOut, correction.ProposedBreaks); msg != "" {
+			return nil, rejectClock(http.StatusConflict, "Correction no longer applies: "+msg)
+		}
+		if err := rejectOverlappingShift(tx, attendance); err != nil {
+			return nil, err
+		}
+		if err := tx.Save(&attendance).Error; err != nil {
+			return nil, err
+		}
+	} else {
+		attendance = models.AttendanceLog{
+			EmployeeID: correction.EmployeeID,
+			ClockIn:    *correction.ProposedClockIn,
+			ClockOut:   correction.ProposedClockOut,
+		}
+		if err := rejectOverlappingShift(tx, attendance); err != nil {
+			return nil, err
+		}
+		if err := tx.Create(&attendance).Error; err != nil {
+			return nil, err
+		}
+		correction.AttendanceID = &attendance.ID
+	}
+
+	if correction.ProposedBreaks != nil {
+		catalog, err := loadBreakCatalog(tx)
+		if err != nil {
+			return nil, err
+		}
+		existing, err := shiftBreakTypes(tx, attendance.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := catalog.CheckShift(existing, proposedBreakTypes(correction.ProposedBreaks)); err != nil {
+			return nil, err
+		}
+
+		if err := tx.Where("attendance_id = ?", attendance.ID).Delete(&models.BreakLog{}).Error; err != nil {
+			return nil, err
+		}
+		for _, b := range correction.ProposedBreaks {
+			breakLog := models.BreakLog{
+				AttendanceID: attendance.ID,
+				BreakType:    normalizeBreakType(b.BreakType),
+				BreakStart:   b.Start,
+				BreakEnd:     b.End,
+			}
+			if err := tx.Create(&breakLog).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &attendance, nil
+}
+
+// reviewCorrection approves or rejects a pending correction in one transaction
+func reviewCorrection(c *gin.Context, approve bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid correction request ID"})
+		return
+	}
+
+	var req struct {
+		Note string `json:"note"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	var correction *models.CorrectionRequest
+	var attendance *models.AttendanceLog
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if correction, err = lockPendingCorrection(tx, id); err != nil {
+			return err
+		}
+
+		pending := *correction
+
+		if approve {
+			// A correction to an existing shift is audited with the shift
+			// and its breaks as they were before it was applied
+			var before interface{}
+			if correction.AttendanceID != nil {
+				snapshot, err := attendanceSnapshot(tx, *correction.AttendanceID)
+				if err != nil {
+					return err
+				}
+				before = snapshot
+			}
+			if attendance, err = applyCorrection(tx, correction); err != nil {
+				return err
+			}
+			after, err := attendanceSnapshot(tx, attendance.ID)
+			if err != nil {
+				return err
+			}
+			if err := recordAudit(tx, auditActor(c), "correction.approve", "attendance", attendance.ID, before, after); err != nil {
+				return err
+			}
+			correction.Status = "approved"
+		} else {
+			correction.Status = "rejected"
+		}
+
+		now := time.Now()
+		adminID := c.GetUint("userID")
+		correction.ReviewedAt = &now
+		correction.ReviewedBy = &adminID
+		correction.ReviewNote = req.Note
+		if err := tx.Save(correction).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "correction."+correction.Status, "correction", correction.ID, pending, correction)
+	})
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Correction would leave more than one open shift or break"})
+		return
+	}
+	if err != nil {
+		respondClockError(c, err, "Failed to review correction request")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Correction request " + correction.Status,
+		"correction": correction,
+		"attendance": attendance,
+	})
+}
+
+// ApproveCorrectionRequest applies a pending correction and records the approver
+func ApproveCorrectionRequest(c *gin.Context) {
+	reviewCorrection(c, true)
+}
+
+// RejectCorrectionRequest closes a pending correction without changing attendance
+func RejectCorrectionRequest(c *gin.Context) {
+	reviewCorrection(c, false)
+}
@@ -6,16 +6,45 @@ import (
 
 	"github.com/aoncodev/qrbackend/initializers"
 	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/shiftstate"
+	"github.com/aoncodev/qrbackend/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
 )
 
+// employeeAuditSnapshot hides the admin OTP from audit entries while still
+// showing that one is set
+func employeeAuditSnapshot(e models.Employee) models.Employee {
+	if e.OTP != "" {
+		e.OTP = "[redacted]"
+	}
+	return e
+}
 
+// GetEmployees lists employees; status=active (default), inactive or all
 func GetEmployees(c *gin.Context) {
+	query := initializers.DB
+	switch c.DefaultQuery("status", "active") {
+	case "active":
+		query = query.Where("active = ?", true)
+	case "inactive":
+		query = query.Where("active = ?", false)
+	case "all":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active, inactive or all"})
+		return
+	}
+
 	var employees []models.Employee
-	if err := initializers.DB.Find(&employees).Error; err != nil {
+	if err := query.Find(&employees).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch employees"})
 		return
 	}
+	if err := withCurrentWages(initializers.DB, employees); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wages"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"employees": employees})
 }
@@ -34,7 +63,16 @@ func CreateEmployee(c *gin.Context) {
 		return
 	}
 
-	if err := initializers.DB.Create(&input).Error; err != nil {
+	// The starting wage becomes the first entry of the wage history
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&input).Error; err != nil {
+			return err
+		}
+		_, err := appendWageRate(tx, &input, input.HourlyWage, utils.BusinessDate(input.CreatedAt), auditActor(c))
+		return err
+	})
+
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create employee"})
 		return
 	}
@@ -51,12 +89,62 @@ func UpdateEmployee(c *gin.Context) {
 		return
 	}
 
-	if err := c.ShouldBindJSON(&employee); err != nil {
+	before := employee
+
+	if err := c.ShouldBindBodyWith(&employee, binding.JSON); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
 
-	if err := initializers.DB.Save(&employee).Error; err != nil {
+	// A wage change is appended to the history, effective today unless
+	// wage_effective_from says otherwise. hourly_wage is read separately so
+	// an update that leaves it out keeps the current rate.
+	var wageReq struct {
+		HourlyWage        *int   `json:"hourly_wage"`
+		WageEffectiveFrom string `json:"wage_effective_from"`
+	}
+	if err := c.ShouldBindBodyWith(&wageReq, binding.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if wageReq.WageEffectiveFrom == "" {
+		wageReq.WageEffectiveFrom = utils.BusinessDate(time.Now())
+	} else if _, err := utils.ParseBusinessDate(wageReq.WageEffectiveFrom); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid wage_effective_from format, use YYYY-MM-DD"})
+		return
+	}
+	employee.HourlyWage = before.HourlyWage
+
+	// Compare against the rate that would otherwise apply from that date
+	history, err := loadWageHistory(initializers.DB, before)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wage history"})
+		return
+	}
+	currentWage := history.RateOn(wageReq.WageEffectiveFrom)
+	before.HourlyWage = history.RateOn(utils.BusinessDate(time.Now())) // audit the rate in effect, not the stored starting wage
+
+	// Deactivation goes through DeleteEmployee/RestoreEmployee only
+	employee.ID = before.ID
+	employee.Active = before.Active
+	employee.TerminationDate = before.TerminationDate
+
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&employee).Error; err != nil {
+			return err
+		}
+		if wageReq.HourlyWage != nil && *wageReq.HourlyWage > 0 && *wageReq.HourlyWage != currentWage {
+			if _, err := appendWageRate(tx, &employee, *wageReq.HourlyWage, wageReq.WageEffectiveFrom, auditActor(c)); err != nil {
+				return err
+			}
+		} else {
+			employee.HourlyWage = before.HourlyWage
+		}
+		return recordAudit(tx, auditActor(c), "employee.update", "employee", employee.ID,
+			employeeAuditSnapshot(before), employeeAuditSnapshot(employee))
+	})
+
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update employee"})
 		return
 	}
@@ -64,14 +152,124 @@ func UpdateEmployee(c *gin.Context) {
 	c.JSON(http.StatusOK, employee)
 }
 
+// DeleteEmployee deactivates an employee instead of deleting the row, so
+// their attendance history stays intact. An optional termination_date
+// (YYYY-MM-DD) sets their last day; it defaults to today. A future date keeps
+// the employee active through their notice period, and the auto clock-out
+// job deactivates them once the date has passed.
 func DeleteEmployee(c *gin.Context) {
 	id := c.Param("id")
-	if err := initializers.DB.Delete(&models.Employee{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete employee"})
+	var employee models.Employee
+
+	if err := initializers.DB.First(&employee, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	var req struct {
+		TerminationDate string `json:"termination_date"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+	today := utils.BusinessDate(time.Now())
+	if req.TerminationDate == "" {
+		req.TerminationDate = today
+	} else if _, err := utils.ParseBusinessDate(req.TerminationDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid termination_date format, use YYYY-MM-DD"})
+		return
+	}
+
+	before := employee
+	employee.Active = req.TerminationDate > today
+	employee.TerminationDate = &req.TerminationDate
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&employee).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "employee.deactivate", "employee", employee.ID,
+			employeeAuditSnapshot(before), employeeAuditSnapshot(employee))
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deactivate employee"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Employee deleted"})
+	message := "Employee deactivated"
+	if employee.Active {
+		message = "Employee will be deactivated after " + req.TerminationDate
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "employee": employee})
+}
+
+// DeactivateTerminatedEmployees deactivates employees whose termination date
+// has passed. It returns how many were deactivated.
+func DeactivateTerminatedEmployees(now time.Time) (int, error) {
+	var employees []models.Employee
+	if err := initializers.DB.
+		Where("active = ? AND termination_date < ?", true, utils.BusinessDate(now)).
+		Find(&employees).Error; err != nil {
+		return 0, err
+	}
+
+	deactivated := 0
+	for _, employee := range employees {
+		before := employee
+		employee.Active = false
+
+		err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(&employee).Error; err != nil {
+				return err
+			}
+			return recordAudit(tx, nil, "employee.deactivate", "employee", employee.ID,
+				employeeAuditSnapshot(before), employeeAuditSnapshot(employee))
+		})
+		if err != nil {
+			return deactivated, err
+		}
+		deactivated++
+	}
+	return deactivated, nil
+}
+
+// RestoreEmployee reactivates a deactivated employee, or cancels a pending
+// termination
+func RestoreEmployee(c *gin.Context) {
+	id := c.Param("id")
+	var employee models.Employee
+
+	if err := initializers.DB.First(&employee, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+	if employee.Active && employee.TerminationDate == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Employee is already active"})
+		return
+	}
+
+	before := employee
+	employee.Active = true
+	employee.TerminationDate = nil
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&employee).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "employee.restore", "employee", employee.ID,
+			employeeAuditSnapshot(before), employeeAuditSnapshot(employee))
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore employee"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Employee restored", "employee": employee})
 }
 
 func GetEmployeeByID(c *gin.Context) {
@@ -82,6 +280,10 @@ func GetEmployeeByID(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
 		return
 	}
+	if err := withCurrentWage(initializers.DB, &employee); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wages"})
+		return
+	}
 
 	c.JSON(http.StatusOK, employee)
 }
@@ -93,31 +295,47 @@ func GetDailyAttendance(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "date query param required (YYYY-MM-DD)"})
 		return
 	}
-	_, err := time.Parse("2006-01-02", dateStr)
-	if err != nil {
+	if _, err := utils.ParseBusinessDate(dateStr); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
 		return
 	}
 
+	// Deactivated employees drop off the list after their termination date
 	var employees []models.Employee
-	if err := initializers.DB.Find(&employees).Error; err != nil {
+	if err := initializers.DB.
+		Where("termination_date IS NULL OR termination_date >= ?", dateStr).
+		Find(&employees).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch employees"})
 		return
 	}
 
+	// Rounding, grace and paid breaks apply as in payroll
+	rules, err := loadPayRules(initializers.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load pay rules"})
+		return
+	}
+	catalog := rules.Breaks
+
 	results := []gin.H{}
+	now := time.Now()
 	for _, emp := range employees {
-		var attendance models.AttendanceLog
-		loc, _ := time.LoadLocation("Asia/Seoul")
-		kstDate, _ := time.ParseInLocation("2006-01-02", dateStr, loc)
-		utcStart := kstDate.UTC()
-		utcEnd := kstDate.Add(24 * time.Hour).UTC()
-
+		var shifts []models.AttendanceLog
 		err := initializers.DB.
-			Where("employee_id = ? AND clock_in >= ? AND clock_in < ?", emp.ID, utcStart, utcEnd).
+			Where("employee_id = ? AND business_date = ?", emp.ID, dateStr).
+			Order("clock_in").
 			Preload("Breaks").
-			First(&attendance).Error
-		if err != nil {
+			Find(&shifts).Error
+		if err != nil || len(shifts) == 0 {
+			// Only employees scheduled to work that day count as absent
+			status := shiftstate.Absent
+			if schedule, err := loadEmployeeSchedule(initializers.DB, emp, dateStr, dateStr); err == nil && !schedule.On(dateStr).Scheduled {
+				status = shiftstate.NotScheduled
+				if _, ok := schedule.HolidayOn(dateStr); ok {
+					status = shiftstate.Holiday
+				}
+			}
+
 			results = append(results, gin.H{
 				"employee_id": emp.ID,
 				"employee": emp.Name,
@@ -126,65 +344,63 @@ func GetDailyAttendance(c *gin.Context) {
 				"total_hours": 0,
 				"breaks": nil,
 				"break_time": 0,
-				"status": "absent",
+				"shifts": []gin.H{},
+				"status": status,
 			})
 			continue
 		}
 
-		// Calculate total break time and check if currently on break
-		totalBreakMinutes := 0
+		// Breaks and shifts across the whole business day
 		breaks := []gin.H{}
-		isOnBreak := false
-		for _, b := range attendance.Breaks {
-			if b.BreakEnd != nil {
-				dur := int(b.BreakEnd.Sub(b.BreakStart).Minutes())
-				breaks = append(breaks, gin.H{
-					"break_type": b.BreakType,
-					"start": b.BreakStart,
-					"end": b.BreakEnd,
-					"duration_minutes": dur,
-				})
-				totalBreakMinutes += dur
-			} else {
-				isOnBreak = true
-				breaks = append(breaks, gin.H{
-					"break_type": b.BreakType,
-					"start": b.BreakStart,
-					"end": nil,
-					"duration_minutes": nil,
-				})
+		shiftRows := []gin.H{}
+		for _, shift := range shifts {
+			for _, b := range shift.Breaks {
+				check := catalog.Check(b, now)
+				if b.BreakEnd != nil {
+					breaks = append(breaks, gin.H{
+						"break_type": b.BreakType,
+						"start": utils.InBusinessTime(b.BreakStart),
+						"end": utils.InBusinessTimeOrNil(b.BreakEnd),
+						"duration_minutes": breakMinutes(b, now),
+						"overdue": check.Overdue,
+						"overdue_minutes": check.OverdueMinutes,
+					})
+				} else {
+					breaks = append(breaks, gin.H{
+						"break_type": b.BreakType,
+						"start": utils.InBusinessTime(b.BreakStart),
+						"end": nil,
+						"duration_minutes": nil,
+						"overdue": check.Overdue,
+						"overdue_minutes": check.OverdueMinutes,
+					})
+				}
 			}
+			shiftRows = append(shiftRows, gin.H{
+				"attendance_id": shift.ID,
+				"clock_in": utils.InBusinessTime(shift.ClockIn),
+				"clock_out": utils.InBusinessTimeOrNil(shift.ClockOut),
+			})
 		}
 
-		var totalHours float64
-		var status string
-		if attendance.ClockOut != nil {
-			workMinutes := int(attendance.ClockOut.Sub(attendance.ClockIn).Minutes()) - totalBreakMinutes
-			if workMinutes < 0 { workMinutes = 0 }
-			totalHours = float64(workMinutes) / 60.0
-			status = "present"
-		} else if isOnBreak {
-			workMinutes := int(time.Now().Sub(attendance.ClockIn).Minutes()) - totalBreakMinutes
-			if workMinutes < 0 { workMinutes = 0 }
-			totalHours = float64(workMinutes) / 60.0
-			status = "on_break"
-		} else {
-			// Not clocked out yet and not on break
-			workMinutes := int(time.Now().Sub(attendance.ClockIn).Minutes()) - totalBreakMinutes
-			if workMinutes < 0 { workMinutes = 0 }
-			totalHours = float64(workMinutes) / 60.0
-			status = "working"
-		}
+		summary := summarizeShifts(shifts, now, rules)
+		status := shiftstate.DayStatus(shiftstate.Of(shiftstate.Snapshot{
+			HasShiftToday: true,
+			ShiftOpen:     summary.Open,
+			BreakOpen:     summary.OnBreak,
+		}))
 
+		// attendance_id refers to the latest shift, which is the one admins act on
 		results = append(results, gin.H{
 			"employee_id": emp.ID,
 			"employee": emp.Name,
-			"attendance_id": attendance.ID,
-			"clock_in": attendance.ClockIn,
-			"clock_out": attendance.ClockOut,
-			"total_hours": totalHours,
+			"attendance_id": shifts[len(shifts)-1].ID,
+			"clock_in": utils.InBusinessTime(summary.FirstClockIn),
+			"clock_out": utils.InBusinessTimeOrNil(summary.LastClockOut),
+			"total_hours": float64(summary.WorkMinutes) / 60.0,
 			"breaks": breaks,
-			"break_time": float64(totalBreakMinutes) / 60.0,
+			"break_time": float64(summary.BreakMinutes) / 60.0,
+			"shifts": shiftRows,
 			"status": status,
 		})
 	}
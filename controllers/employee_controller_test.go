@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/gin-gonic/gin"
+)
+
+func putEmployee(t *testing.T, id, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: id}}
+	c.Request = httptest.NewRequest(http.MethodPut, "/api/employees/"+id, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	UpdateEmployee(c)
+	return w
+}
+
+func TestUpdateEmployeeWage(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantWage  int
+		wantRates []int
+	}{
+		{
+			name:      "an update without hourly_wage keeps the wage history",
+			body:      `{"name": "Kim Min", "start_time": "10:00"}`,
+			wantWage:  10030,
+			wantRates: []int{9860, 10030},
+		},
+		{
+			name:      "the current wage is not appended again",
+			body:      `{"name": "Kim", "hourly_wage": 10030}`,
+			wantWage:  10030,
+			wantRates: []int{9860, 10030},
+		},
+		{
+			name:      "a new wage is appended from today",
+			body:      `{"name": "Kim", "hourly_wage": 10500}`,
+			wantWage:  10500,
+			wantRates: []int{9860, 10030, 10500},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useTestDB(t, &models.Employee{}, &models.WageRate{}, &models.AuditLog{})
+			employee := models.Employee{Name: "Kim", QRID: "EMP-0001", HourlyWage: 9860, Role: "employee", StartTime: "09:00", Active: true}
+			if err := db.Create(&employee).Error; err != nil {
+				t.Fatal(err)
+			}
+			rates := []models.WageRate{
+				{EmployeeID: employee.ID, HourlyWage: 9860, EffectiveFrom: "2024-01-01"},
+				{EmployeeID: employee.ID, HourlyWage: 10030, EffectiveFrom: "2025-01-01"},
+			}
+			if err := db.Create(&rates).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			w := putEmployee(t, "1", tt.body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+			}
+
+			var got models.Employee
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.HourlyWage != tt.wantWage {
+				t.Errorf("response hourly_wage = %d, want %d", got.HourlyWage, tt.wantWage)
+			}
+
+			var stored []models.WageRate
+			if err := db.Where("employee_id = ?", employee.ID).Order("effective_from").Find(&stored).Error; err != nil {
+				t.Fatal(err)
+			}
+			var storedRates []int
+			for _, r := range stored {
+				storedRates = append(storedRates, r.HourlyWage)
+			}
+			if len(storedRates) != len(tt.wantRates) {
+				t.Fatalf("wage history = %v, want %v", storedRates, tt.wantRates)
+			}
+			for i := range storedRates {
+				if storedRates[i] != tt.wantRates[i] {
+					t.Fatalf("wage history = %v, want %v", storedRates, tt.wantRates)
+				}
+			}
+
+			var row models.Employee
+			if err := db.First(&row, employee.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if row.HourlyWage != 9860 {
+				t.Errorf("stored starting wage = %d, want 9860", row.HourlyWage)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// useTestDB points initializers.DB at a fresh in-memory SQLite database with
+// the given tables and rebuilds the QR verifier on top of it
+func useTestDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("QR_SIGNING_SECRET", "test-secret")
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to :memory: is its own database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+
+	previous, previousVerifier := initializers.DB, initializers.QRVerifier
+	initializers.DB = db
+	initializers.LoadQRVerifier()
+	t.Cleanup(func() {
+		initializers.DB = previous
+		initializers.QRVerifier = previousVerifier
+	})
+	return db
+}
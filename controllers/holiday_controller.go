@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// loadHolidays returns the holidays between startDate and endDate by date
+func loadHolidays(db *gorm.DB, startDate, endDate string) (map[string]models.Holiday, error) {
+	var holidays []models.Holiday
+	if err := db.Where("date BETWEEN ? AND ?", startDate, endDate).Find(&holidays).Error; err != nil {
+		return nil, err
+	}
+	byDate := map[string]models.Holiday{}
+	for _, h := range holidays {
+		byDate[h.Date] = h
+	}
+	return byDate, nil
+}
+
+// GetHolidays lists holidays, optionally between start_date and end_date
+func GetHolidays(c *gin.Context) {
+	query := initializers.DB.Order("date")
+	if startDate := c.Query("start_date"); startDate != "" {
+		query = query.Where("date >= ?", startDate)
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		query = query.Where("date <= ?", endDate)
+	}
+
+	var holidays []models.Holiday
+	if err := query.Find(&holidays).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch holidays"})
+		return
+	}
+
+	c.JSON(http.StatusOK, holidays)
+}
+
+type holidayInput struct {
+	Date string `json:"date" binding:"required"`
+	Name string `json:"name" binding:"required"`
+}
+
+// CreateHoliday adds one holiday
+func CreateHoliday(c *gin.Context) {
+	var req holidayInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date and name are required"})
+		return
+	}
+	if _, err := utils.ParseBusinessDate(req.Date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
+		return
+	}
+
+	holiday := models.Holiday{Date: req.Date, Name: req.Name}
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&holiday).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "holiday.create", "holiday", holiday.ID, nil, holiday)
+	})
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A holiday already exists on this date"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create holiday"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, holiday)
+}
+
+// UpdateHoliday changes a holiday's date or name
+func UpdateHoliday(c *gin.Context) {
+	var holiday models.Holiday
+	if err := initializers.DB.First(&holiday, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Holiday not found"})
+		return
+	}
+
+	var req holidayInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date and name are required"})
+		return
+	}
+	if _, err := utils.ParseBusinessDate(req.Date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
+		return
+	}
+
+	before := holiday
+	holiday.Date = req.Date
+	holiday.Name = req.Name
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&holiday).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "holiday.update", "holiday", holiday.ID, before, holiday)
+	})
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A holiday already exists on this date"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update holiday"})
+		return
+	}
+
+	c.JSON(http.StatusOK, holiday)
+}
+
+// DeleteHoliday removes a holiday from the calendar
+func DeleteHoliday(c *gin.Context) {
+	var holiday models.Holiday
+	if err := initializers.DB.First(&holiday, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Holiday not found"})
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&holiday).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "holiday.delete", "holiday", holiday.ID, holiday, nil)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete holiday"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Holiday deleted"})
+}
+
+// ImportHolidays adds holidays from an uploaded iCalendar (.ics) or CSV
+// (date,name) file in the multipart field "file". A holiday already on the
+// calendar for an imported date is renamed to the imported name.
+func ImportHolidays(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	defer file.Close()
+
+	format := strings.ToLower(c.PostForm("format"))
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(header.Filename)) {
+		case ".ics", ".ical":
+			format = "ics"
+		case ".csv":
+			format = "csv"
+		}
+	}
+
+	var entries []utils.HolidayEntry
+	switch format {
+	case "ics":
+		entries, err = utils.ParseHolidayICS(io.LimitReader(file, 5<<20))
+	case "csv":
+		entries, err = utils.ParseHolidayCSV(io.LimitReader(file, 5<<20))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported file type, upload .ics or .csv or pass format"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read holidays: " + err.Error()})
+		return
+	}
+	if len(entries) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No holidays found in file"})
+		return
+	}
+
+	// The last entry for a date wins
+	byDate := map[string]models.Holiday{}
+	for _, e := range entries {
+		name := e.Name
+		if name == "" {
+			name = "Holiday"
+		}
+		byDate[e.Date] = models.Holiday{Date: e.Date, Name: name}
+	}
+	dates := make([]string, 0, len(byDate))
+	for date := range byDate {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	// Each created or renamed holiday is audited on its own row
+	imported := 0
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var existing []models.Holiday
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("date IN ?", dates).Find(&existing).Error; err != nil {
+			return err
+		}
+		existingByDate := map[string]models.Holiday{}
+		for _, h := range existing {
+			existingByDate[h.Date] = h
+		}
+
+		for _, date := range dates {
+			holiday := byDate[date]
+			before, ok := existingByDate[date]
+			if !ok {
+				if err := tx.Create(&holiday).Error; err != nil {
+					return err
+				}
+				if err := recordAudit(tx, auditActor(c), "holiday.import", "holiday", holiday.ID, nil, holiday); err != nil {
+					return err
+				}
+				imported++
+				continue
+			}
+			if before.Name == holiday.Name {
+				continue
+			}
+
+			holiday = before
+			holiday.Name = byDate[date].Name
+			if err := tx.Save(&holiday).Error; err != nil {
+				return err
+			}
+			if err := recordAudit(tx, auditActor(c), "holiday.import", "holiday", holiday.ID, before, holiday); err != nil {
+				return err
+			}
+			imported++
+		}
+		return nil
+	})
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Holidays were changed during the import, try again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import holidays"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Holidays imported",
+		"imported": imported,
+		"days":     len(dates),
+	})
+}
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashKioskKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func GetKiosks(c *gin.Context) {
+	var kiosks []models.Kiosk
+	if err := initializers.DB.Order("id").Find(&kiosks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch kiosks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"kiosks": kiosks})
+}
+
+// CreateKiosk registers a kiosk and returns its device key. The key is only
+// shown once; the kiosk sends it as X-Kiosk-Key to fetch its current code.
+func CreateKiosk(c *gin.Context) {
+	var req struct {
+		Name     string `json:"name" binding:"required"`
+		Location string `json:"location"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	secret, err := randomHex(20)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate kiosk secret"})
+		return
+	}
+	apiKey, err := randomHex(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate kiosk key"})
+		return
+	}
+
+	kiosk := models.Kiosk{
+		Name:       req.Name,
+		Location:   req.Location,
+		Secret:     secret,
+		APIKeyHash: hashKioskKey(apiKey),
+		Active:     true,
+	}
+
+	if err := initializers.DB.Create(&kiosk).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create kiosk"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"kiosk":   kiosk,
+		"api_key": apiKey,
+	})
+}
+
+func DeactivateKiosk(c *gin.Context) {
+	id := c.Param("id")
+	var kiosk models.Kiosk
+
+	if err := initializers.DB.First(&kiosk, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Kiosk not found"})
+		return
+	}
+
+	if err := initializers.DB.Model(&kiosk).Update("active", false).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deactivate kiosk"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Kiosk deactivated"})
+}
+
+// authenticateKiosk loads the active kiosk identified by the X-Kiosk-Key header
+func authenticateKiosk(c *gin.Context) (*models.Kiosk, bool) {
+	key := c.GetHeader("X-Kiosk-Key")
+	if key == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing kiosk key"})
+		return nil, false
+	}
+
+	var kiosk models.Kiosk
+	if err := initializers.DB.Where("api_key_hash = ? AND active = ?", hashKioskKey(key), true).First(&kiosk).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid kiosk key"})
+		return nil, false
+	}
+	return &kiosk, true
+}
+
+// GetKioskCode returns the code the kiosk should currently display as a QR
+func GetKioskCode(c *gin.Context) {
+	kiosk, ok := authenticateKiosk(c)
+	if !ok {
+		return
+	}
+
+	secret, err := hex.DecodeString(kiosk.Secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Kiosk secret is corrupt"})
+		return
+	}
+
+	now := time.Now()
+	counter := utils.KioskCodeCounter(now)
+	validUntil := time.Unix(int64(counter+1)*int64(utils.KioskCodePeriod/time.Second), 0)
+
+	c.JSON(http.StatusOK, gin.H{
+		"kiosk_id":    kiosk.ID,
+		"code":        utils.KioskCode(secret, counter),
+		"valid_until": validUntil,
+		"period":      int(utils.KioskCodePeriod / time.Second),
+	})
+}
+
+// KioskClockIn clocks in the signed-in employee after they scan the code a
+// kiosk is displaying, which proves they are standing at that kiosk
+func KioskClockIn(c *gin.Context) {
+	var req struct {
+		KioskID uint   `json:"kiosk_id" binding:"required"`
+		Code    string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kiosk_id and code are required"})
+		return
+	}
+
+	var kiosk models.Kiosk
+	if err := initializers.DB.Where("id = ? AND active = ?", req.KioskID, true).First(&kiosk).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Kiosk not found"})
+		return
+	}
+
+	secret, err := hex.DecodeString(kiosk.Secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Kiosk secret is corrupt"})
+		return
+	}
+
+	if !utils.VerifyKioskCode(secret, req.Code, time.Now(), 1) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Kiosk code is invalid or expired"})
+		return
+	}
+
+	var employee models.Employee
+	if err := initializers.DB.First(&employee, c.GetUint("userID")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	clockInEmployee(c, &employee)
+}
@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aoncodev/qrbackend/compliance"
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/payroll"
+	"github.com/aoncodev/qrbackend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// scheduledMinutes is the contracted work time of a scheduled day: its length
+// less the unpaid break the break rules require for it, 0 when its end is
+// unknown
+func scheduledMinutes(day scheduledDay, date string, breakRules []compliance.BreakRule) int {
+	start, ok := day.Start(date)
+	if !ok {
+		return 0
+	}
+	end, ok := day.End(date)
+	if !ok {
+		return 0
+	}
+	span := int(end.Sub(start).Minutes())
+	return span - compliance.ScheduledBreakMinutes(breakRules, span)
+}
+
+// isoWeek labels the ISO week of a business date
+func isoWeek(date string) string {
+	day, err := utils.ParseBusinessDate(date)
+	if err != nil {
+		return ""
+	}
+	return payroll.ISOWeek(day)
+}
+
+// payrollWeek lays out the seven days starting at monday for the weekly
+// holiday allowance. Only completed shifts count as worked minutes, but any
+// shift counts as attendance.
+func payrollWeek(monday time.Time, schedule employeeSchedule, shiftsByDay map[string][]models.AttendanceLog, rules *payRules, breakRules []compliance.BreakRule) []payroll.Day {
+	week := make([]payroll.Day, 0, 7)
+	for i := 0; i < 7; i++ {
+		date := monday.AddDate(0, 0, i).Format("2006-01-02")
+		planned, holiday := schedule.Contracted(date)
+		minutes := scheduledMinutes(planned, date, breakRules)
+
+		day := payroll.Day{
+			Date:             date,
+			Scheduled:        minutes > 0,
+			Holiday:          holiday,
+			ScheduledMinutes: minutes,
+			Attended:         len(shiftsByDay[date]) > 0,
+		}
+
+		var completed []models.AttendanceLog
+		for _, shift := range shiftsByDay[date] {
+			if shift.ClockOut != nil {
+				completed = append(completed, shift)
+			}
+		}
+		if len(completed) > 0 {
+			day.WorkedMinutes = summarizeShifts(completed, time.Now(), rules).WorkMinutes
+		}
+		week = append(week, day)
+	}
+	return week
+}
+
+// GetEmployeePayroll computes pay for employee_id between start_date and
+// end_date: wages for hours worked, with overtime, night and holiday
+// premiums, plus the weekly holiday allowance. An ISO
+// week's allowance is paid in the period containing its Sunday; weeks that
+// end after end_date are listed but not totalled.
+func GetEmployeePayroll(c *gin.Context) {
+	employeeID := c.Query("employee_id")
+	startDate := c.Query("start_date")
+	endDate := c.Query("end_date")
+
+	if employeeID == "" || startDate == "" || endDate == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "employee_id, start_date, and end_date are required"})
+		return
+	}
+
+	start, err := utils.ParseBusinessDate(startDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start_date format, use YYYY-MM-DD"})
+		return
+	}
+	end, err := utils.ParseBusinessDate(endDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end_date format, use YYYY-MM-DD"})
+		return
+	}
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return
+	}
+
+	var employee models.Employee
+	if err := initializers.DB.First(&employee, employeeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	// Weekly items need whole ISO weeks around the period
+	firstMonday := payroll.WeekStart(start)
+	lastSunday := payroll.WeekStart(end).AddDate(0, 0, 6)
+	from := firstMonday.Format("2006-01-02")
+	to := lastSunday.Format("2006-01-02")
+
+	var attendanceLogs []models.AttendanceLog
+	if err := initializers.DB.
+		Where("employee_id = ? AND business_date BETWEEN ? AND ?", employee.ID, from, to).
+		Order("clock_in").
+		Preload("Breaks").
+		Find(&attendanceLogs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load attendance logs"})
+		return
+	}
+	shiftsByDay := map[string][]models.AttendanceLog{}
+	for _, log := range attendanceLogs {
+		shiftsByDay[log.BusinessDate] = append(shiftsByDay[log.BusinessDate], log)
+	}
+
+	wages, err := loadWageHistory(initializers.DB, employee)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load wage history"})
+		return
+	}
+
+	schedule, err := loadEmployeeSchedule(initializers.DB, employee, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load schedule"})
+		return
+	}
+
+	rules, err := loadPayRules(initializers.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load pay rules"})
+		return
+	}
+
+	breakRules, err := loadBreakRules(initializers.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load break rules"})
+		return
+	}
+
+	calc, err := newPayCalculator(initializers.DB, wages)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load pay rules"})
+		return
+	}
+
+	// Price every completed shift of the surrounding weeks so weekly overtime
+	// is counted from Monday, then report the days inside the period
+	segmentsByDay := map[string][]payroll.Segment{}
+	for _, segment := range priceShifts(calc, attendanceLogs, rules) {
+		segmentsByDay[segment.Date] = append(segmentsByDay[segment.Date], segment)
+	}
+
+	days := []gin.H{}
+	var periodSegments []payroll.Segment
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		segments := segmentsByDay[date]
+		if len(segments) == 0 {
+			continue
+		}
+
+		periodSegments = append(periodSegments, segments...)
+		pay := payroll.SumSegments(segments)
+		days = append(days, gin.H{
+			"date":         date,
+			"iso_week":     payroll.ISOWeek(d),
+			"worked_hours": pay.Minutes / 60.0,
+			"hourly_wage":  wages.RateOn(date),
+			"base_wage":    pay.BaseWage,
+			"premium_wage": pay.PremiumWage,
+			"wage":         pay.Wage,
+			"segments":     segments,
+		})
+	}
+	totals := payroll.SumSegments(periodSegments)
+
+	// Weekly holiday allowance for every week touching the period
+	today := utils.BusinessDate(time.Now())
+	weeks := []gin.H{}
+	allowanceTotal := 0.0
+	for monday := firstMonday; !monday.After(end); monday = monday.AddDate(0, 0, 7) {
+		sunday := monday.AddDate(0, 0, 6).Format("2006-01-02")
+		finished := today > sunday
+		employed := employee.TerminationDate == nil || *employee.TerminationDate > sunday
+
+		allowance := payroll.WeeklyHolidayAllowanceFor(
+			payrollWeek(monday, schedule, shiftsByDay, rules, breakRules),
+			wages.RateOn(sunday), finished, employed,
+		)
+		paidInPeriod := sunday >= startDate && sunday <= endDate
+		if paidInPeriod {
+			allowanceTotal += allowance.Amount
+		}
+		weeks = append(weeks, gin.H{
+			"allowance":      allowance,
+			"paid_in_period": paidInPeriod,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"employee_id":              employee.ID,
+		"employee":                 employee.Name,
+		"start_date":               startDate,
+		"end_date":                 endDate,
+		"days":                     days,
+		"weeks":                    weeks,
+		"worked_hours":             totals.Minutes / 60.0,
+		"overtime_hours":           totals.OvertimeMinutes / 60.0,
+		"night_hours":              totals.NightMinutes / 60.0,
+		"holiday_hours":            totals.HolidayMinutes / 60.0,
+		"base_wage":                totals.BaseWage,
+		"premium_wage":             totals.PremiumWage,
+		"weekly_holiday_allowance": allowanceTotal,
+		"total_pay":                totals.Wage + allowanceTotal,
+	})
+}
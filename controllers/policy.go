@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const attendancePolicyID = 1
+
+// envMinutes reads a non-negative minute count from env, defaulting to 0
+func envMinutes(key string) int {
+	minutes, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || minutes < 0 {
+		return 0
+	}
+	return minutes
+}
+
+// defaultAttendancePolicy applies until an admin saves a policy. The grace
+// periods can be seeded from LATE_GRACE_MINUTES and EARLY_LEAVE_GRACE_MINUTES.
+func defaultAttendancePolicy() models.AttendancePolicy {
+	return models.AttendancePolicy{
+		ID:                     attendancePolicyID,
+		LateGraceMinutes:       envMinutes("LATE_GRACE_MINUTES"),
+		EarlyLeaveGraceMinutes: envMinutes("EARLY_LEAVE_GRACE_MINUTES"),
+		ClockInRounding:        models.RoundNearest,
+		ClockOutRounding:       models.RoundNearest,
+		BreakRounding:          models.RoundNearest,
+	}
+}
+
+// loadAttendancePolicy returns the saved policy or the defaults
+func loadAttendancePolicy(db *gorm.DB) (models.AttendancePolicy, error) {
+	var policy models.AttendancePolicy
+	err := db.First(&policy, attendancePolicyID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return defaultAttendancePolicy(), nil
+	}
+	return policy, err
+}
+
+func validRoundingMode(mode string) bool {
+	return mode == models.RoundNearest || mode == models.RoundUp || mode == models.RoundDown
+}
+
+// validRoundingStep accepts 0 (off) or a step that divides an hour evenly
+func validRoundingStep(step int) bool {
+	return step == 0 || (step > 0 && step <= 60 && 60%step == 0)
+}
+
+// GetAttendancePolicy returns the grace and rounding rules in effect
+func GetAttendancePolicy(c *gin.Context) {
+	policy, err := loadAttendancePolicy(initializers.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load attendance policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdateAttendancePolicy replaces the grace and rounding rules. Rounding
+// applies to the times used for pay; lateness and early leave are always
+// measured on the actual clock times.
+func UpdateAttendancePolicy(c *gin.Context) {
+	var req struct {
+		LateGraceMinutes       int    `json:"late_grace_minutes"`
+		EarlyLeaveGraceMinutes int    `json:"early_leave_grace_minutes"`
+		ClockRoundingMinutes   int    `json:"clock_rounding_minutes"`
+		ClockInRounding        string `json:"clock_in_rounding"`
+		ClockOutRounding       string `json:"clock_out_rounding"`
+		BreakRoundingMinutes   int    `json:"break_rounding_minutes"`
+		BreakRounding          string `json:"break_rounding"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if req.LateGraceMinutes < 0 || req.EarlyLeaveGraceMinutes < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "grace minutes must not be negative"})
+		return
+	}
+	if !validRoundingStep(req.ClockRoundingMinutes) || !validRoundingStep(req.BreakRoundingMinutes) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rounding minutes must be 0 or divide 60 evenly (e.g. 5, 15)"})
+		return
+	}
+
+	// Unset modes keep the default
+	modes := []*string{&req.ClockInRounding, &req.ClockOutRounding, &req.BreakRounding}
+	for _, mode := range modes {
+		if *mode == "" {
+			*mode = models.RoundNearest
+		}
+		if !validRoundingMode(*mode) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "rounding must be nearest, up or down"})
+			return
+		}
+	}
+
+	var policy models.AttendancePolicy
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		before, err := loadAttendancePolicy(tx)
+		if err != nil {
+			return err
+		}
+
+		policy = models.AttendancePolicy{
+			ID:                     attendancePolicyID,
+			LateGraceMinutes:       req.LateGraceMinutes,
+			EarlyLeaveGraceMinutes: req.EarlyLeaveGraceMinutes,
+			ClockRoundingMinutes:   req.ClockRoundingMinutes,
+			ClockInRounding:        req.ClockInRounding,
+			ClockOutRounding:       req.ClockOutRounding,
+			BreakRoundingMinutes:   req.BreakRoundingMinutes,
+			BreakRounding:          req.BreakRounding,
+			UpdatedBy:              auditActor(c),
+		}
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&policy).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "policy.update", "attendance_policy", attendancePolicyID, before, policy)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update attendance policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/payroll"
+	"github.com/aoncodev/qrbackend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const premiumPayRuleID = 1
+
+// defaultPremiumPayRule applies until an admin saves the premium settings
+func defaultPremiumPayRule() models.PremiumPayRule {
+	rules := payroll.DefaultPremiumRules
+	return models.PremiumPayRule{
+		ID:                     premiumPayRuleID,
+		DailyOvertimeMinutes:   rules.DailyOvertimeMinutes,
+		WeeklyOvertimeMinutes:  rules.WeeklyOvertimeMinutes,
+		OvertimePremium:        rules.OvertimePremium,
+		NightStart:             rules.NightStart,
+		NightEnd:               rules.NightEnd,
+		NightPremium:           rules.NightPremium,
+		HolidayPremium:         rules.HolidayPremium,
+		HolidayOvertimePremium: rules.HolidayOvertimePremium,
+		RestWeekdays:           models.Weekdays{},
+	}
+}
+
+// loadPremiumPayRule returns the saved premium settings or the defaults
+func loadPremiumPayRule(db *gorm.DB) (models.PremiumPayRule, error) {
+	var rule models.PremiumPayRule
+	err := db.First(&rule, premiumPayRuleID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return defaultPremiumPayRule(), nil
+	}
+	return rule, err
+}
+
+// newPayCalculator prices shifts with the premium settings, the holiday
+// calendar and the employee's wage history
+func newPayCalculator(db *gorm.DB, wages wageHistory) (payroll.Calculator, error) {
+	rule, err := loadPremiumPayRule(db)
+	if err != nil {
+		return payroll.Calculator{}, err
+	}
+
+	restDays := map[time.Weekday]bool{}
+	for _, w := range rule.RestWeekdays {
+		restDays[time.Weekday(w)] = true
+	}
+
+	// The calendar is small, so load all of it rather than guess the range
+	var holidays []models.Holiday
+	if err := db.Find(&holidays).Error; err != nil {
+		return payroll.Calculator{}, err
+	}
+	holidayDates := map[string]bool{}
+	for _, h := range holidays {
+		holidayDates[h.Date] = true
+	}
+
+	return payroll.Calculator{
+		Rules: payroll.PremiumRules{
+			DailyOvertimeMinutes:   rule.DailyOvertimeMinutes,
+			WeeklyOvertimeMinutes:  rule.WeeklyOvertimeMinutes,
+			OvertimePremium:        rule.OvertimePremium,
+			NightStart:             rule.NightStart,
+			NightEnd:               rule.NightEnd,
+			NightPremium:           rule.NightPremium,
+			HolidayPremium:         rule.HolidayPremium,
+			HolidayOvertimePremium: rule.HolidayOvertimePremium,
+		},
+		Location: utils.BusinessLocation(),
+		IsHoliday: func(date string) bool {
+			if holidayDates[date] {
+				return true
+			}
+			day, err := utils.ParseBusinessDate(date)
+			return err == nil && restDays[day.Weekday()]
+		},
+		RateOn: wages.RateOn,
+	}, nil
+}
+
+// workIntervals is the paid work of a completed shift: the (rounded) shift
+// minus the unpaid part of each break. The unpaid part of a paid break that
+// ran over its limit is its tail.
+func workIntervals(shift models.AttendanceLog, rules *payRules) []payroll.Interval {
+	start := utils.RoundClock(shift.ClockIn, rules.Policy.ClockRoundingMinutes, rules.Policy.ClockInRounding)
+	end := utils.RoundClock(*shift.ClockOut, rules.Policy.ClockRoundingMinutes, rules.Policy.ClockOutRounding)
+	if !end.After(start) {
+		return nil
+	}
+
+	var unpaid []payroll.Interval
+	for _, b := range shift.Breaks {
+		minutes := utils.RoundMinutes(breakMinutes(b, *shift.ClockOut), rules.Policy.BreakRoundingMinutes, rules.Policy.BreakRounding)
+		breakEnd := b.BreakStart.Add(time.Duration(minutes) * time.Minute)
+		deducted := rules.Breaks.UnpaidMinutes(b.BreakType, minutes)
+		if deducted > 0 {
+			unpaid = append(unpaid, payroll.Interval{
+				Start: breakEnd.Add(-time.Duration(deducted) * time.Minute),
+				End:   breakEnd,
+			})
+		}
+	}
+	sort.Slice(unpaid, func(i, j int) bool { return unpaid[i].Start.Before(unpaid[j].Start) })
+
+	intervals := []payroll.Interval{}
+	cursor := start
+	for _, u := range unpaid {
+		if u.Start.After(cursor) {
+			stop := u.Start
+			if stop.After(end) {
+				stop = end
+			}
+			if stop.After(cursor) {
+				intervals = append(intervals, payroll.Interval{Start: cursor, End: stop})
+			}
+		}
+		if u.End.After(cursor) {
+			cursor = u.End
+		}
+	}
+	if end.After(cursor) {
+		intervals = append(intervals, payroll.Interval{Start: cursor, End: end})
+	}
+	return intervals
+}
+
+// priceShifts splits completed shifts, in clock-in order, into paid segments
+func priceShifts(calc payroll.Calculator, shifts []models.AttendanceLog, rules *payRules) []payroll.Segment {
+	work := make([]payroll.WorkShift, 0, len(shifts))
+	for _, shift := range shifts {
+		if shift.ClockOut == nil {
+			continue
+		}
+		work = append(work, payroll.WorkShift{
+			ID:           shift.ID,
+			BusinessDate: shift.BusinessDate,
+			Intervals:    workIntervals(shift, rules),
+		})
+	}
+	return calc.Price(work)
+}
+
+// loadWeekLeadIn returns the completed shifts from the Monday of startDate's
+// ISO week up to the day before startDate, so weekly overtime in a period
+// that starts mid-week counts the earlier days
+func loadWeekLeadIn(db *gorm.DB, employeeID uint, startDate string) ([]models.AttendanceLog, error) {
+	start, err := utils.ParseBusinessDate(startDate)
+	if err != nil {
+		return nil, err
+	}
+	var shifts []models.AttendanceLog
+	err = db.
+		Where("employee_id = ? AND business_date >= ? AND business_date < ? AND clock_out IS NOT NULL",
+			employeeID, payroll.WeekStart(start).Format("2006-01-02"), startDate).
+		Order("clock_in").
+		Preload("Breaks").
+		Find(&shifts).Error
+	return shifts, err
+}
+
+// GetPremiumPayRules returns the overtime, night and holiday premium settings
+func GetPremiumPayRules(c *gin.Context) {
+	rule, err := loadPremiumPayRule(initializers.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load pay rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdatePremiumPayRules replaces the premium settings
+func UpdatePremiumPayRules(c *gin.Context) {
+	var req struct {
+		DailyOvertimeMinutes   int     `json:"daily_overtime_minutes"`
+		WeeklyOvertimeMinutes  int     `json:"weekly_overtime_minutes"`
+		OvertimePremium        float64 `json:"overtime_premium"`
+		NightStart             string  `json:"night_start"`
+		NightEnd               string  `json:"night_end"`
+		NightPremium           float64 `json:"night_premium"`
+		HolidayPremium         float64 `json:"holiday_premium"`
+		HolidayOvertimePremium float64 `json:"holiday_overtime_premium"`
+		RestWeekdays           []int   `json:"rest_weekdays"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if req.DailyOvertimeMinutes < 0 || req.WeeklyOvertimeMinutes < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "overtime thresholds must not be negative"})
+		return
+	}
+	if req.OvertimePremium < 0 || req.NightPremium < 0 || req.HolidayPremium < 0 || req.HolidayOvertimePremium < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "premiums must not be negative"})
+		return
+	}
+	if !validClock(req.NightStart) || !validClock(req.NightEnd) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "night_start and night_end must be HH:MM"})
+		return
+	}
+	restWeekdays := models.Weekdays{}
+	seen := map[int]bool{}
+	for _, w := range req.RestWeekdays {
+		if w < 0 || w > 6 || seen[w] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "rest_weekdays must be 0-6 and appear once"})
+			return
+		}
+		seen[w] = true
+		restWeekdays = append(restWeekdays, w)
+	}
+
+	var rule models.PremiumPayRule
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		before, err := loadPremiumPayRule(tx)
+		if err != nil {
+			return err
+		}
+
+		rule = models.PremiumPayRule{
+			ID:                     premiumPayRuleID,
+			DailyOvertimeMinutes:   req.DailyOvertimeMinutes,
+			WeeklyOvertimeMinutes:  req.WeeklyOvertimeMinutes,
+			OvertimePremium:        req.OvertimePremium,
+			NightStart:             req.NightStart,
+			NightEnd:               req.NightEnd,
+			NightPremium:           req.NightPremium,
+			HolidayPremium:         req.HolidayPremium,
+			HolidayOvertimePremium: req.HolidayOvertimePremium,
+			RestWeekdays:           restWeekdays,
+			UpdatedBy:              auditActor(c),
+		}
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&rule).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "pay_rules.update", "premium_pay_rule", premiumPayRuleID, before, rule)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update pay rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/aoncodev/qrbackend/models"
+)
+
+// punctuality is how far a day's attendance strayed from its scheduled hours
+type punctuality struct {
+	LateMinutes       int
+	EarlyLeaveMinutes int
+	OvertimeMinutes   int
+}
+
+// measurePunctuality compares a day's first clock-in and last clock-out with
+// the scheduled hours. Deviations inside the policy's grace period count as
+// zero; past it the full deviation is reported. Days without a scheduled end
+// report no early leave or overtime.
+func measurePunctuality(policy models.AttendancePolicy, day scheduledDay, date string, clockIn time.Time, clockOut *time.Time) punctuality {
+	var p punctuality
+	lateGrace := time.Duration(policy.LateGraceMinutes) * time.Minute
+	earlyLeaveGrace := time.Duration(policy.EarlyLeaveGraceMinutes) * time.Minute
+
+	if start, ok := day.Start(date); ok {
+		if late := clockIn.Sub(start); late > lateGrace {
+			p.LateMinutes = int(late.Minutes())
+		}
+	}
+
+	end, ok := day.End(date)
+	if !ok || clockOut == nil {
+		return p
+	}
+	if early := end.Sub(*clockOut); early > earlyLeaveGrace {
+		p.EarlyLeaveMinutes = int(early.Minutes())
+	}
+	if over := clockOut.Sub(end); over > 0 {
+		p.OvertimeMinutes = int(over.Minutes())
+	}
+	return p
+}
@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/qrauth"
+	"github.com/aoncodev/qrbackend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RotateEmployeeQR invalidates all previously issued QR tokens for an
+// employee and returns a freshly signed one
+func RotateEmployeeQR(c *gin.Context) {
+	id := c.Param("id")
+	var employee models.Employee
+
+	if err := initializers.DB.First(&employee, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	qrID, err := utils.RandomQRID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate QR code"})
+		return
+	}
+
+	// The legacy QR ID is retired along with the old tokens
+	now := time.Now()
+	if err := initializers.DB.Model(&employee).Updates(map[string]interface{}{"qr_valid_from": now, "qr_id": qrID}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate QR code"})
+		return
+	}
+
+	token, err := utils.GenerateQRToken(employee.ID, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate QR code"})
+		return
+	}
+
+	response := gin.H{
+		"employee_id": employee.ID,
+		"qr_token":    token,
+		"issued_at":   now,
+		"expires_at":  nil,
+	}
+	if ttl := qrauth.TokenTTL(); ttl > 0 {
+		response["expires_at"] = now.Add(ttl)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeEmployeeQR invalidates all QR tokens for an employee without issuing a new one
+func RevokeEmployeeQR(c *gin.Context) {
+	id := c.Param("id")
+	var employee models.Employee
+
+	if err := initializers.DB.First(&employee, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	qrID, err := utils.RandomQRID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke QR code"})
+		return
+	}
+
+	// Push the cutoff one second ahead so a token signed in the same second is
+	// also rejected, and replace the legacy QR ID so the printed one stops working
+	if err := initializers.DB.Model(&employee).Updates(map[string]interface{}{"qr_valid_from": time.Now().Add(time.Second), "qr_id": qrID}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke QR code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "QR codes revoked"})
+}
+
+// parseQRImageOptions reads size, level and margin query params
+func parseQRImageOptions(c *gin.Context) (utils.QRImageOptions, bool) {
+	opts := utils.QRImageOptions{Size: 256, Level: c.DefaultQuery("level", "M"), Margin: 4}
+
+	if v := c.Query("size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 32 || size > 2048 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "size must be between 32 and 2048"})
+			return opts, false
+		}
+		opts.Size = size
+	}
+
+	if v := c.Query("margin"); v != "" {
+		margin, err := strconv.Atoi(v)
+		if err != nil || margin < 0 || margin > 16 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "margin must be between 0 and 16"})
+			return opts, false
+		}
+		opts.Margin = margin
+	}
+
+	return opts, true
+}
+
+// badgeQRContent returns what the badge should encode: a freshly signed
+// token by default, or the legacy QR ID when payload is "legacy" and the
+// verifier still accepts legacy IDs (QR_ALLOW_LEGACY_UNTIL)
+func badgeQRContent(c *gin.Context, employee models.Employee, payload string) (string, bool) {
+	switch payload {
+	case "", "token":
+	case "legacy":
+		if until, ok := qrauth.LegacyUntil(); !ok || !time.Now().Before(until) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Legacy QR IDs are no longer accepted"})
+			return "", false
+		}
+		return employee.QRID, true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "payload must be token or legacy"})
+		return "", false
+	}
+
+	token, err := utils.GenerateQRToken(employee.ID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate QR code"})
+		return "", false
+	}
+	return token, true
+}
+
+// GetEmployeeQRImage renders an employee's badge QR as PNG or SVG depending on the route
+func GetEmployeeQRImage(format string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		var employee models.Employee
+
+		if err := initializers.DB.First(&employee, id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+			return
+		}
+
+		opts, ok := parseQRImageOptions(c)
+		if !ok {
+			return
+		}
+
+		content, ok := badgeQRContent(c, employee, c.Query("payload"))
+		if !ok {
+			return
+		}
+
+		var (
+			data        []byte
+			err         error
+			contentType string
+		)
+		if format == "svg" {
+			data, err = utils.RenderQRSVG(content, opts)
+			contentType = "image/svg+xml"
+		} else {
+			data, err = utils.RenderQRPNG(content, opts)
+			contentType = "image/png"
+		}
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Header("Cache-Control", "no-store")
+		c.Data(http.StatusOK, contentType, data)
+	}
+}
+
+// ExportBadgeSheet returns a printable PDF of badges for the selected
+// employees, or for all employees when employee_ids is empty
+func ExportBadgeSheet(c *gin.Context) {
+	var req struct {
+		EmployeeIDs []uint             `json:"employee_ids"`
+		Template    string             `json:"template"`
+		Layout      *utils.BadgeLayout `json:"layout"`
+		Payload     string             `json:"payload"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	// A custom layout takes precedence over a named template
+	var layout utils.BadgeLayout
+	if req.Layout != nil {
+		layout = *req.Layout
+	} else {
+		if req.Template == "" {
+			req.Template = utils.DefaultBadgeLayout
+		}
+		var found bool
+		if layout, found = utils.BadgeLayouts[req.Template]; !found {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown badge template"})
+			return
+		}
+	}
+
+	if err := layout.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var employees []models.Employee
+	query := initializers.DB.Order("name")
+	if len(req.EmployeeIDs) > 0 {
+		query = query.Where("id IN ?", req.EmployeeIDs)
+	} else {
+		query = query.Where("active = ?", true)
+	}
+	if err := query.Find(&employees).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch employees"})
+		return
+	}
+
+	badges := make([]utils.Badge, 0, len(employees))
+	for _, employee := range employees {
+		content, ok := badgeQRContent(c, employee, req.Payload)
+		if !ok {
+			return
+		}
+		badges = append(badges, utils.Badge{Name: employee.Name, Role: employee.Role, QRContent: content})
+	}
+
+	data, err := utils.RenderBadgeSheet(badges, layout)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render badge sheet"})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="badges.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
+// GetBadgeTemplates lists the built-in badge sheet layouts
+func GetBadgeTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"default":   utils.DefaultBadgeLayout,
+		"templates": utils.BadgeLayouts,
+	})
+}
@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"bytes"
+	"compress/zlib"
+	"image"
+	_ "image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// decodeQR reads the text of the QR code in img
+func decodeQR(t *testing.T, img image.Image) string {
+	t.Helper()
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		t.Fatalf("binarize QR image: %v", err)
+	}
+	result, err := qrcode.NewQRCodeReader().Decode(bitmap, nil)
+	if err != nil {
+		t.Fatalf("decode QR image: %v", err)
+	}
+	return result.GetText()
+}
+
+func getQRImage(t *testing.T, id, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: id}}
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/employees/"+id+"/qr.png?"+query, nil)
+	GetEmployeeQRImage("png")(c)
+	return w
+}
+
+func TestGetEmployeeQRImage(t *testing.T) {
+	db := useTestDB(t, &models.Employee{})
+	employee := models.Employee{Name: "Kim", QRID: "EMP-0001", HourlyWage: 10030, Role: "employee", StartTime: "09:00", Active: true}
+	if err := db.Create(&employee).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("default badge scans", func(t *testing.T) {
+		w := getQRImage(t, "1", "size=256")
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+		}
+		img, _, err := image.Decode(bytes.NewReader(w.Body.Bytes()))
+		if err != nil {
+			t.Fatalf("decode PNG: %v", err)
+		}
+
+		code := decodeQR(t, img)
+		if code == employee.QRID {
+			t.Fatalf("default badge encodes the legacy QR ID")
+		}
+		got, err := initializers.QRVerifier.Verify(code)
+		if err != nil {
+			t.Fatalf("Verify(default badge) = %v", err)
+		}
+		if got.ID != employee.ID {
+			t.Errorf("badge resolves to employee %d, want %d", got.ID, employee.ID)
+		}
+	})
+
+	t.Run("legacy badge is refused without a legacy window", func(t *testing.T) {
+		if w := getQRImage(t, "1", "payload=legacy"); w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("legacy badge scans inside the legacy window", func(t *testing.T) {
+		t.Setenv("QR_ALLOW_LEGACY_UNTIL", "2999-01-01")
+		initializers.LoadQRVerifier()
+
+		w := getQRImage(t, "1", "payload=legacy&size=256")
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+		}
+		img, _, err := image.Decode(bytes.NewReader(w.Body.Bytes()))
+		if err != nil {
+			t.Fatalf("decode PNG: %v", err)
+		}
+
+		code := decodeQR(t, img)
+		if code != employee.QRID {
+			t.Fatalf("legacy badge encodes %q, want %q", code, employee.QRID)
+		}
+		if _, err := initializers.QRVerifier.Verify(code); err != nil {
+			t.Fatalf("Verify(legacy badge) = %v", err)
+		}
+	})
+
+	t.Run("unknown payload", func(t *testing.T) {
+		if w := getQRImage(t, "1", "payload=raw"); w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+var pdfImageHeader = regexp.MustCompile(`(?s)/Subtype /Image\n/Width (\d+)\n/Height (\d+)\n/ColorSpace /DeviceGray\n.*?/Length (\d+)>>\nstream\n`)
+
+// pdfImages extracts the grayscale PNG images embedded in a PDF by fpdf,
+// which stores the zlib data of the PNG with its row filters intact
+func pdfImages(t *testing.T, pdf []byte) []image.Image {
+	t.Helper()
+	var images []image.Image
+	for _, m := range pdfImageHeader.FindAllSubmatchIndex(pdf, -1) {
+		width, _ := strconv.Atoi(string(pdf[m[2]:m[3]]))
+		height, _ := strconv.Atoi(string(pdf[m[4]:m[5]]))
+		length, _ := strconv.Atoi(string(pdf[m[6]:m[7]]))
+
+		zr, err := zlib.NewReader(bytes.NewReader(pdf[m[1] : m[1]+length]))
+		if err != nil {
+			t.Fatalf("inflate PDF image: %v", err)
+		}
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("inflate PDF image: %v", err)
+		}
+		if len(data) != height*(width+1) {
+			t.Fatalf("PDF image has %d bytes, want %d", len(data), height*(width+1))
+		}
+
+		img := image.NewGray(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			filter, row := data[y*(width+1)], data[y*(width+1)+1:(y+1)*(width+1)]
+			for x := range row {
+				var left, up, upLeft int
+				if x > 0 {
+					left = int(img.Pix[y*width+x-1])
+				}
+				if y > 0 {
+					up = int(img.Pix[(y-1)*width+x])
+					if x > 0 {
+						upLeft = int(img.Pix[(y-1)*width+x-1])
+					}
+				}
+				predicted := 0
+				switch filter {
+				case 1:
+					predicted = left
+				case 2:
+					predicted = up
+				case 3:
+					predicted = (left + up) / 2
+				case 4:
+					predicted = paeth(left, up, upLeft)
+				}
+				img.Pix[y*width+x] = row[x] + byte(predicted)
+			}
+		}
+		images = append(images, img)
+	}
+	return images
+}
+
+func paeth(a, b, c int) int {
+	p := a + b - c
+	pa, pb, pc := abs(p-a), abs(p-b), abs(p-c)
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func TestExportBadgeSheet(t *testing.T) {
+	db := useTestDB(t, &models.Employee{})
+	employees := []models.Employee{
+		{Name: "Kim", QRID: "EMP-0001", HourlyWage: 10030, Role: "employee", StartTime: "09:00", Active: true},
+		{Name: "Lee", QRID: "EMP-0002", HourlyWage: 10030, Role: "employee", StartTime: "10:00", Active: true},
+		{Name: "Park", QRID: "EMP-0003", HourlyWage: 10030, Role: "admin", StartTime: "08:00", Active: true},
+	}
+	if err := db.Create(&employees).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/employees/badges/pdf", strings.NewReader(`{"employee_ids": [1, 2, 3]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	ExportBadgeSheet(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+
+	images := pdfImages(t, w.Body.Bytes())
+	if len(images) != len(employees) {
+		t.Fatalf("sheet has %d QR images, want %d", len(images), len(employees))
+	}
+
+	// fpdf does not keep the images in badge order
+	scanned := map[uint]bool{}
+	for i, img := range images {
+		code := decodeQR(t, img)
+		got, err := initializers.QRVerifier.Verify(code)
+		if err != nil {
+			t.Errorf("Verify(badge image %d) = %v", i, err)
+			continue
+		}
+		scanned[got.ID] = true
+	}
+	for _, employee := range employees {
+		if !scanned[employee.ID] {
+			t.Errorf("no badge on the sheet scans as %s", employee.Name)
+		}
+	}
+}
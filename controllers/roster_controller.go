@@ -0,0 +1,469 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type plannedShiftInput struct {
+	EmployeeID uint   `json:"employee_id" binding:"required"`
+	Date       string `json:"date" binding:"required"`
+	StartTime  string `json:"start_time" binding:"required"`
+	EndTime    string `json:"end_time" binding:"required"`
+	Position   string `json:"position"`
+	Note       string `json:"note"`
+}
+
+func (in plannedShiftInput) validate() string {
+	if _, err := utils.ParseBusinessDate(in.Date); err != nil {
+		return "invalid date format, use YYYY-MM-DD"
+	}
+	if !validClock(in.StartTime) || !validClock(in.EndTime) {
+		return "start_time and end_time must be HH:MM"
+	}
+	if in.StartTime == in.EndTime {
+		return "start_time and end_time must differ"
+	}
+	return ""
+}
+
+// employedOn reports whether an employee can be rostered on a business
+// date: they must be active and not past their termination date
+func employedOn(employee models.Employee, date string) bool {
+	return employee.Active && (employee.TerminationDate == nil || *employee.TerminationDate >= date)
+}
+
+// plannedInterval returns the instants a planned shift covers
+func plannedInterval(shift models.PlannedShift) (time.Time, time.Time, bool) {
+	day := scheduledDay{Scheduled: true, StartTime: shift.StartTime, EndTime: shift.EndTime}
+	start, ok := day.Start(shift.Date)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	end, ok := day.End(shift.Date)
+	return start, end, ok
+}
+
+// rosterConflicts returns the employee's other planned shifts that overlap shift
+func rosterConflicts(db *gorm.DB, shift models.PlannedShift) ([]models.PlannedShift, error) {
+	start, end, ok := plannedInterval(shift)
+	if !ok {
+		return nil, nil
+	}
+
+	// Overnight shifts can reach into the neighbouring dates
+	day, _ := utils.ParseBusinessDate(shift.Date)
+	from := day.AddDate(0, 0, -1).Format("2006-01-02")
+	to := day.AddDate(0, 0, 1).Format("2006-01-02")
+
+	var nearby []models.PlannedShift
+	query := db.Where("employee_id = ? AND date BETWEEN ? AND ?", shift.EmployeeID, from, to)
+	if shift.ID != 0 {
+		query = query.Where("id <> ?", shift.ID)
+	}
+	if err := query.Find(&nearby).Error; err != nil {
+		return nil, err
+	}
+
+	conflicts := []models.PlannedShift{}
+	for _, other := range nearby {
+		otherStart, otherEnd, ok := plannedInterval(other)
+		if ok && start.Before(otherEnd) && otherStart.Before(end) {
+			conflicts = append(conflicts, other)
+		}
+	}
+	return conflicts, nil
+}
+
+// GetRoster lists planned shifts between start_date and end_date, optionally for one employee
+func GetRoster(c *gin.Context) {
+	startDate := c.Query("start_date")
+	endDate := c.Query("end_date")
+	if startDate == "" || endDate == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date are required"})
+		return
+	}
+
+	query := initializers.DB.Where("date BETWEEN ? AND ?", startDate, endDate).Order("date, start_time")
+	if employeeID := c.Query("employee_id"); employeeID != "" {
+		query = query.Where("employee_id = ?", employeeID)
+	}
+
+	var shifts []models.PlannedShift
+	if err := query.Find(&shifts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch roster"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shifts": shifts})
+}
+
+// CreatePlannedShift adds a shift to the roster unless it overlaps another
+// shift of the same employee or falls outside their employment
+func CreatePlannedShift(c *gin.Context) {
+	var req plannedShiftInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "employee_id, date, start_time and end_time are required"})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	var employee models.Employee
+	if err := initializers.DB.First(&employee, req.EmployeeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	shift := models.PlannedShift{
+		EmployeeID: req.EmployeeID,
+		Date:       req.Date,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+		Position:   req.Position,
+		Note:       req.Note,
+	}
+	savePlannedShift(c, &shift, nil, http.StatusCreated)
+}
+
+// UpdatePlannedShift replaces a planned shift's fields
+func UpdatePlannedShift(c *gin.Context) {
+	var shift models.PlannedShift
+	if err := initializers.DB.First(&shift, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Planned shift not found"})
+		return
+	}
+
+	var req plannedShiftInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "employee_id, date, start_time and end_time are required"})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	before := shift
+	shift.EmployeeID = req.EmployeeID
+	shift.Date = req.Date
+	shift.StartTime = req.StartTime
+	shift.EndTime = req.EndTime
+	shift.Position = req.Position
+	shift.Note = req.Note
+	savePlannedShift(c, &shift, &before, http.StatusOK)
+}
+
+// savePlannedShift checks that the employee is employed on the shift's date
+// and has no overlapping shift, and saves the shift with an audit entry
+func savePlannedShift(c *gin.Context, shift *models.PlannedShift, before *models.PlannedShift, status int) {
+	var conflicts []models.PlannedShift
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		// Serialise roster edits per employee so two overlapping inserts can't both pass
+		employee, err := lockEmployee(tx, shift.EmployeeID)
+		if err != nil {
+			return err
+		}
+		if !employedOn(*employee, shift.Date) {
+			return rejectClock(http.StatusBadRequest, "Employee is not employed on this date")
+		}
+
+		if conflicts, err = rosterConflicts(tx, *shift); err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			return rejectClock(http.StatusConflict, "Shift overlaps another planned shift")
+		}
+
+		if err := tx.Save(shift).Error; err != nil {
+			return err
+		}
+		action := "roster.create"
+		var beforeSnapshot interface{}
+		if before != nil {
+			action = "roster.update"
+			beforeSnapshot = before
+		}
+		return recordAudit(tx, auditActor(c), action, "planned_shift", shift.ID, beforeSnapshot, shift)
+	})
+
+	if len(conflicts) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Shift overlaps another planned shift", "conflicts": conflicts})
+		return
+	}
+	if err != nil {
+		respondClockError(c, err, "Failed to save planned shift")
+		return
+	}
+
+	c.JSON(status, shift)
+}
+
+// DeletePlannedShift removes a shift from the roster
+func DeletePlannedShift(c *gin.Context) {
+	var shift models.PlannedShift
+	if err := initializers.DB.First(&shift, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Planned shift not found"})
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&shift).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "roster.delete", "planned_shift", shift.ID, shift, nil)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete planned shift"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Planned shift deleted"})
+}
+
+// CopyRosterWeek copies the seven days starting at from_week_start to the
+// week starting at to_week_start (default: the following week). Shifts that
+// would overlap an existing shift, or fall outside the employee's employment,
+// are skipped and reported.
+func CopyRosterWeek(c *gin.Context) {
+	var req struct {
+		FromWeekStart string `json:"from_week_start" binding:"required"`
+		ToWeekStart   string `json:"to_week_start"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from_week_start is required"})
+		return
+	}
+
+	from, err := utils.ParseBusinessDate(req.FromWeekStart)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from_week_start format, use YYYY-MM-DD"})
+		return
+	}
+	to := from.AddDate(0, 0, 7)
+	if req.ToWeekStart != "" {
+		if to, err = utils.ParseBusinessDate(req.ToWeekStart); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to_week_start format, use YYYY-MM-DD"})
+			return
+		}
+	}
+	offsetDays := int(to.Sub(from).Hours()/24 + 0.5)
+	if offsetDays < 7 && offsetDays > -7 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "weeks must not overlap"})
+		return
+	}
+
+	var source []models.PlannedShift
+	if err := initializers.DB.
+		Where("date BETWEEN ? AND ?", from.Format("2006-01-02"), from.AddDate(0, 0, 6).Format("2006-01-02")).
+		Order("date, start_time").
+		Find(&source).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch roster"})
+		return
+	}
+
+	created := []models.PlannedShift{}
+	skipped := []gin.H{}
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock every employee up front, in ID order, so single-shift saves
+		// can't slip an overlap in and concurrent copies can't deadlock
+		var employeeIDs []uint
+		seen := map[uint]bool{}
+		for _, s := range source {
+			if !seen[s.EmployeeID] {
+				seen[s.EmployeeID] = true
+				employeeIDs = append(employeeIDs, s.EmployeeID)
+			}
+		}
+		sort.Slice(employeeIDs, func(i, j int) bool { return employeeIDs[i] < employeeIDs[j] })
+		employees := map[uint]*models.Employee{}
+		for _, id := range employeeIDs {
+			employee, err := lockEmployee(tx, id)
+			if err != nil {
+				return err
+			}
+			employees[id] = employee
+		}
+
+		for _, s := range source {
+			day, _ := utils.ParseBusinessDate(s.Date)
+			shift := models.PlannedShift{
+				EmployeeID: s.EmployeeID,
+				Date:       day.AddDate(0, 0, offsetDays).Format("2006-01-02"),
+				StartTime:  s.StartTime,
+				EndTime:    s.EndTime,
+				Position:   s.Position,
+				Note:       s.Note,
+			}
+
+			if !employedOn(*employees[s.EmployeeID], shift.Date) {
+				skipped = append(skipped, gin.H{"source_id": s.ID, "shift": shift, "reason": "employee is not employed on this date"})
+				continue
+			}
+
+			conflicts, err := rosterConflicts(tx, shift)
+			if err != nil {
+				return err
+			}
+			if len(conflicts) > 0 {
+				skipped = append(skipped, gin.H{"source_id": s.ID, "shift": shift, "reason": "overlaps another planned shift", "conflicts": conflicts})
+				continue
+			}
+
+			if err := tx.Create(&shift).Error; err != nil {
+				return err
+			}
+			if err := recordAudit(tx, auditActor(c), "roster.copy_week", "planned_shift", shift.ID, nil, shift); err != nil {
+				return err
+			}
+			created = append(created, shift)
+		}
+		return nil
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to copy roster"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"created": created,
+		"skipped": skipped,
+	})
+}
+
+// CompareRoster lines up planned shifts with actual attendance for one date
+func CompareRoster(c *gin.Context) {
+	date := c.Query("date")
+	if _, err := utils.ParseBusinessDate(date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date query param required (YYYY-MM-DD)"})
+		return
+	}
+
+	var planned []models.PlannedShift
+	if err := initializers.DB.Where("date = ?", date).Order("start_time").Find(&planned).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch roster"})
+		return
+	}
+
+	var actual []models.AttendanceLog
+	if err := initializers.DB.Where("business_date = ?", date).Order("clock_in, id").Preload("Breaks").Find(&actual).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attendance"})
+		return
+	}
+
+	var employees []models.Employee
+	if err := initializers.DB.Find(&employees).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch employees"})
+		return
+	}
+	rules, err := loadPayRules(initializers.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load pay rules"})
+		return
+	}
+
+	names := map[uint]string{}
+	for _, e := range employees {
+		names[e.ID] = e.Name
+	}
+
+	actualByEmployee := map[uint][]models.AttendanceLog{}
+	for _, a := range actual {
+		actualByEmployee[a.EmployeeID] = append(actualByEmployee[a.EmployeeID], a)
+	}
+
+	now := time.Now()
+	rows := []gin.H{}
+	plannedEmployees := map[uint]bool{}
+	for _, p := range planned {
+		plannedEmployees[p.EmployeeID] = true
+		start, end, _ := plannedInterval(p)
+
+		// Match attendance that overlaps the planned window
+		var matched []models.AttendanceLog
+		for _, a := range actualByEmployee[p.EmployeeID] {
+			clockOut := now
+			if a.ClockOut != nil {
+				clockOut = *a.ClockOut
+			}
+			if a.ClockIn.Before(end) && start.Before(clockOut) {
+				matched = append(matched, a)
+			}
+		}
+
+		row := gin.H{
+			"planned_shift_id":    p.ID,
+			"employee_id":         p.EmployeeID,
+			"employee":            names[p.EmployeeID],
+			"position":            p.Position,
+			"planned_start":       utils.InBusinessTime(start),
+			"planned_end":         utils.InBusinessTime(end),
+			"actual_clock_in":     nil,
+			"actual_clock_out":    nil,
+			"late_minutes":        0,
+			"early_leave_minutes": 0,
+			"overtime_minutes":    0,
+			"worked_hours":        0.0,
+		}
+
+		if len(matched) == 0 {
+			row["status"] = "missing"
+		} else {
+			summary := summarizeShifts(matched, now, rules)
+			row["actual_clock_in"] = utils.InBusinessTime(summary.FirstClockIn)
+			row["actual_clock_out"] = utils.InBusinessTimeOrNil(summary.LastClockOut)
+			row["worked_hours"] = float64(summary.WorkMinutes) / 60.0
+			planDay := scheduledDay{Scheduled: true, StartTime: p.StartTime, EndTime: p.EndTime}
+			timing := measurePunctuality(rules.Policy, planDay, p.Date, summary.FirstClockIn, summary.LastClockOut)
+			row["late_minutes"] = timing.LateMinutes
+			row["early_leave_minutes"] = timing.EarlyLeaveMinutes
+			row["overtime_minutes"] = timing.OvertimeMinutes
+			if summary.Open {
+				row["status"] = "in_progress"
+			} else {
+				row["status"] = "worked"
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	// Attendance by employees who were not on the roster that day, in order
+	// of their first clock-in
+	unplanned := []gin.H{}
+	listed := map[uint]bool{}
+	for _, a := range actual {
+		employeeID := a.EmployeeID
+		if plannedEmployees[employeeID] || listed[employeeID] {
+			continue
+		}
+		listed[employeeID] = true
+
+		shifts := actualByEmployee[employeeID]
+		summary := summarizeShifts(shifts, now, rules)
+		unplanned = append(unplanned, gin.H{
+			"employee_id":      employeeID,
+			"employee":         names[employeeID],
+			"actual_clock_in":  utils.InBusinessTime(summary.FirstClockIn),
+			"actual_clock_out": utils.InBusinessTimeOrNil(summary.LastClockOut),
+			"worked_hours":     float64(summary.WorkMinutes) / 60.0,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"date":      date,
+		"planned":   rows,
+		"unplanned": unplanned,
+	})
+}
@@ -0,0 +1,395 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// scheduledDay is what an employee was supposed to work on one business date
+type scheduledDay struct {
+	Scheduled bool
+	StartTime string // "HH:MM"
+	EndTime   string // "HH:MM", empty when only a start time is known
+}
+
+// Start is the scheduled start instant on date
+func (d scheduledDay) Start(date string) (time.Time, bool) {
+	if !d.Scheduled || d.StartTime == "" {
+		return time.Time{}, false
+	}
+	start, err := utils.BusinessTimeOn(date, d.StartTime)
+	return start, err == nil
+}
+
+// End is the scheduled end instant on date; an end at or before the start
+// belongs to the next day (overnight shift)
+func (d scheduledDay) End(date string) (time.Time, bool) {
+	start, ok := d.Start(date)
+	if !ok || d.EndTime == "" {
+		return time.Time{}, false
+	}
+	end, err := utils.BusinessTimeOn(date, d.EndTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if !end.After(start) {
+		next := start.AddDate(0, 0, 1)
+		end, err = utils.BusinessTimeOn(next.Format("2006-01-02"), d.EndTime)
+		if err != nil {
+			return time.Time{}, false
+		}
+	}
+	return end, true
+}
+
+// employeeSchedule resolves scheduled days from overrides, the roster, the
+// holiday calendar, the weekly pattern and, for employees without a weekly
+// pattern, Employee.StartTime
+type employeeSchedule struct {
+	legacyStart string
+	weekly      map[time.Weekday]models.WeeklySchedule
+	overrides   map[string]models.ScheduleOverride
+	roster      map[string]scheduledDay
+	holidays    map[string]models.Holiday
+}
+
+// loadEmployeeSchedule loads the weekly pattern, and the overrides, roster
+// shifts and holidays between startDate and endDate (inclusive)
+func loadEmployeeSchedule(db *gorm.DB, employee models.Employee, startDate, endDate string) (employeeSchedule, error) {
+	schedule := employeeSchedule{
+		legacyStart: employee.StartTime,
+		weekly:      map[time.Weekday]models.WeeklySchedule{},
+		overrides:   map[string]models.ScheduleOverride{},
+		roster:      map[string]scheduledDay{},
+	}
+
+	var weekly []models.WeeklySchedule
+	if err := db.Where("employee_id = ?", employee.ID).Find(&weekly).Error; err != nil {
+		return schedule, err
+	}
+	for _, w := range weekly {
+		schedule.weekly[time.Weekday(w.Weekday)] = w
+	}
+
+	var overrides []models.ScheduleOverride
+	if err := db.Where("employee_id = ? AND date BETWEEN ? AND ?", employee.ID, startDate, endDate).Find(&overrides).Error; err != nil {
+		return schedule, err
+	}
+	for _, o := range overrides {
+		schedule.overrides[o.Date] = o
+	}
+
+	holidays, err := loadHolidays(db, startDate, endDate)
+	if err != nil {
+		return schedule, err
+	}
+	schedule.holidays = holidays
+
+	// Planned shifts span the day from the earliest start to the latest end
+	var planned []models.PlannedShift
+	if err := db.Where("employee_id = ? AND date BETWEEN ? AND ?", employee.ID, startDate, endDate).Find(&planned).Error; err != nil {
+		return schedule, err
+	}
+	for _, p := range planned {
+		day, ok := schedule.roster[p.Date]
+		if !ok {
+			schedule.roster[p.Date] = scheduledDay{Scheduled: true, StartTime: p.StartTime, EndTime: p.EndTime}
+			continue
+		}
+		start, end, _ := plannedInterval(p)
+		if dayStart, _ := day.Start(p.Date); start.Before(dayStart) {
+			day.StartTime = p.StartTime
+		}
+		if dayEnd, _ := day.End(p.Date); end.After(dayEnd) {
+			day.EndTime = p.EndTime
+		}
+		schedule.roster[p.Date] = day
+	}
+
+	return schedule, nil
+}
+
+// On returns the scheduled hours for a business date
+func (s employeeSchedule) On(date string) scheduledDay {
+	day, holiday := s.Contracted(date)
+	if holiday {
+		return scheduledDay{}
+	}
+	return day
+}
+
+// Contracted returns the hours the employee is contracted for on a business
+// date and whether a holiday gives them that day off. Holidays are days off
+// unless an override or the roster says otherwise, but their contracted
+// hours still count toward the week, e.g. for the weekly holiday allowance.
+func (s employeeSchedule) Contracted(date string) (scheduledDay, bool) {
+	if o, ok := s.overrides[date]; ok {
+		if o.DayOff {
+			return scheduledDay{}, false
+		}
+		return scheduledDay{Scheduled: true, StartTime: o.StartTime, EndTime: o.EndTime}, false
+	}
+
+	if day, ok := s.roster[date]; ok {
+		return day, false
+	}
+
+	day := s.regular(date)
+	_, holiday := s.holidays[date]
+	return day, holiday && day.Scheduled
+}
+
+// regular returns the weekly pattern, or the legacy start time, for a date
+func (s employeeSchedule) regular(date string) scheduledDay {
+	if len(s.weekly) == 0 {
+		// No weekly pattern yet: every day starts at the legacy StartTime
+		return scheduledDay{Scheduled: s.legacyStart != "", StartTime: s.legacyStart}
+	}
+
+	day, err := utils.ParseBusinessDate(date)
+	if err != nil {
+		return scheduledDay{}
+	}
+	if w, ok := s.weekly[day.Weekday()]; ok {
+		return scheduledDay{Scheduled: true, StartTime: w.StartTime, EndTime: w.EndTime}
+	}
+	return scheduledDay{}
+}
+
+// HolidayOn returns the holiday on a business date, if any
+func (s employeeSchedule) HolidayOn(date string) (models.Holiday, bool) {
+	holiday, ok := s.holidays[date]
+	return holiday, ok
+}
+
+func validClock(value string) bool {
+	_, err := time.Parse("15:04", value)
+	return err == nil && len(value) == 5
+}
+
+// GetEmployeeSchedule returns the weekly pattern and the overrides and
+// holidays between optional start_date and end_date
+func GetEmployeeSchedule(c *gin.Context) {
+	id := c.Param("id")
+	var employee models.Employee
+
+	if err := initializers.DB.First(&employee, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	var weekly []models.WeeklySchedule
+	if err := initializers.DB.Where("employee_id = ?", employee.ID).Order("weekday").Find(&weekly).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedule"})
+		return
+	}
+
+	overrideQuery := initializers.DB.Where("employee_id = ?", employee.ID).Order("date")
+	holidayQuery := initializers.DB.Order("date")
+	if startDate := c.Query("start_date"); startDate != "" {
+		overrideQuery = overrideQuery.Where("date >= ?", startDate)
+		holidayQuery = holidayQuery.Where("date >= ?", startDate)
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		overrideQuery = overrideQuery.Where("date <= ?", endDate)
+		holidayQuery = holidayQuery.Where("date <= ?", endDate)
+	}
+
+	var overrides []models.ScheduleOverride
+	if err := overrideQuery.Find(&overrides).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedule"})
+		return
+	}
+
+	var holidays []models.Holiday
+	if err := holidayQuery.Find(&holidays).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch holidays"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"employee_id": employee.ID,
+		"weekly":      weekly,
+		"overrides":   overrides,
+		"holidays":    holidays,
+	})
+}
+
+// SetEmployeeWeeklySchedule replaces the weekly pattern. Weekdays left out
+// of the list become days off.
+func SetEmployeeWeeklySchedule(c *gin.Context) {
+	id := c.Param("id")
+	var employee models.Employee
+
+	if err := initializers.DB.First(&employee, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	var req struct {
+		Days []struct {
+			Weekday   int    `json:"weekday"`
+			StartTime string `json:"start_time"`
+			EndTime   string `json:"end_time"`
+		} `json:"days"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	seen := map[int]bool{}
+	var weekly []models.WeeklySchedule
+	for _, d := range req.Days {
+		if d.Weekday < 0 || d.Weekday > 6 || seen[d.Weekday] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "weekday must be 0-6 and appear once"})
+			return
+		}
+		if !validClock(d.StartTime) || !validClock(d.EndTime) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start_time and end_time must be HH:MM"})
+			return
+		}
+		seen[d.Weekday] = true
+		weekly = append(weekly, models.WeeklySchedule{
+			EmployeeID: employee.ID,
+			Weekday:    d.Weekday,
+			StartTime:  d.StartTime,
+			EndTime:    d.EndTime,
+		})
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var before []models.WeeklySchedule
+		if err := tx.Where("employee_id = ?", employee.ID).Order("weekday").Find(&before).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("employee_id = ?", employee.ID).Delete(&models.WeeklySchedule{}).Error; err != nil {
+			return err
+		}
+		if len(weekly) > 0 {
+			if err := tx.Create(&weekly).Error; err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx, auditActor(c), "schedule.weekly.replace", "employee", employee.ID, before, weekly)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update schedule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Schedule updated successfully",
+		"weekly":  weekly,
+	})
+}
+
+// SetEmployeeScheduleOverride creates or replaces the schedule for one date
+func SetEmployeeScheduleOverride(c *gin.Context) {
+	id := c.Param("id")
+	var employee models.Employee
+
+	if err := initializers.DB.First(&employee, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	var req struct {
+		Date      string `json:"date" binding:"required"`
+		DayOff    bool   `json:"day_off"`
+		StartTime string `json:"start_time"`
+		EndTime   string `json:"end_time"`
+		Note      string `json:"note"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date is required"})
+		return
+	}
+	if _, err := utils.ParseBusinessDate(req.Date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format, use YYYY-MM-DD"})
+		return
+	}
+	if !req.DayOff && (!validClock(req.StartTime) || !validClock(req.EndTime)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_time and end_time must be HH:MM unless day_off is set"})
+		return
+	}
+	if req.DayOff {
+		req.StartTime, req.EndTime = "", ""
+	}
+
+	override := models.ScheduleOverride{
+		EmployeeID: employee.ID,
+		Date:       req.Date,
+		DayOff:     req.DayOff,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+		Note:       req.Note,
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		// Audit the override being replaced, if there is one
+		var before interface{}
+		var existing models.ScheduleOverride
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("employee_id = ? AND date = ?", employee.ID, req.Date).
+			First(&existing).Error
+		switch {
+		case err == nil:
+			before = existing
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		if err := tx.Where("employee_id = ? AND date = ?", employee.ID, req.Date).Delete(&models.ScheduleOverride{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&override).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "schedule.override.set", "employee", employee.ID, before, override)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save schedule override"})
+		return
+	}
+
+	c.JSON(http.StatusOK, override)
+}
+
+// DeleteEmployeeScheduleOverride removes the override for a date so the weekly pattern applies again
+func DeleteEmployeeScheduleOverride(c *gin.Context) {
+	id := c.Param("id")
+	date := c.Param("date")
+
+	var override models.ScheduleOverride
+	if err := initializers.DB.Where("employee_id = ? AND date = ?", id, date).First(&override).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule override not found"})
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&override).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, auditActor(c), "schedule.override.delete", "employee", override.EmployeeID, override, nil)
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete schedule override"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule override deleted"})
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/utils"
+	"gorm.io/gorm"
+)
+
+// shiftSummary aggregates all shifts an employee worked on one business day
+type shiftSummary struct {
+	FirstClockIn       time.Time
+	LastClockOut       *time.Time // nil while any shift is still open
+	WorkMinutes        int        // paid minutes
+	BreakMinutes       int        // all break minutes
+	UnpaidBreakMinutes int        // break minutes deducted from WorkMinutes
+	Open               bool
+	OnBreak            bool
+}
+
+// payRules are the settings that turn clock times into paid minutes
+type payRules struct {
+	Policy models.AttendancePolicy
+	Breaks breakCatalog
+}
+
+func loadPayRules(db *gorm.DB) (*payRules, error) {
+	policy, err := loadAttendancePolicy(db)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := loadBreakCatalog(db)
+	if err != nil {
+		return nil, err
+	}
+	return &payRules{Policy: policy, Breaks: catalog}, nil
+}
+
+// breakMinutes is the length of a break; open breaks run until now
+func breakMinutes(b models.BreakLog, now time.Time) int {
+	end := now
+	if b.BreakEnd != nil {
+		end = *b.BreakEnd
+	}
+	return int(end.Sub(b.BreakStart).Minutes())
+}
+
+// summarizeShifts expects shifts ordered by clock-in. Open shifts are
+// counted up to now. With pay rules, minutes use the rounded clock and break
+// times and only unpaid break time is deducted; FirstClockIn and LastClockOut
+// always stay the actual times. Nil rules give raw minutes with every break
+// deducted.
+func summarizeShifts(shifts []models.AttendanceLog, now time.Time, rules *payRules) shiftSummary {
+	var summary shiftSummary
+	for i, shift := range shifts {
+		if i == 0 {
+			summary.FirstClockIn = shift.ClockIn
+		}
+
+		start := shift.ClockIn
+		end := now
+		if shift.ClockOut != nil {
+			end = *shift.ClockOut
+		} else {
+			summary.Open = true
+		}
+
+		shiftBreaks, unpaidBreaks := 0, 0
+		for _, b := range shift.Breaks {
+			if b.BreakEnd == nil && shift.ClockOut == nil {
+				summary.OnBreak = true
+			}
+			minutes := breakMinutes(b, end)
+			unpaid := minutes
+			if rules != nil {
+				minutes = utils.RoundMinutes(minutes, rules.Policy.BreakRoundingMinutes, rules.Policy.BreakRounding)
+				unpaid = rules.Breaks.UnpaidMinutes(b.BreakType, minutes)
+			}
+			shiftBreaks += minutes
+			unpaidBreaks += unpaid
+		}
+
+		if rules != nil {
+			start = utils.RoundClock(start, rules.Policy.ClockRoundingMinutes, rules.Policy.ClockInRounding)
+			if shift.ClockOut != nil {
+				end = utils.RoundClock(end, rules.Policy.ClockRoundingMinutes, rules.Policy.ClockOutRounding)
+			}
+		}
+
+		workMinutes := int(end.Sub(start).Minutes()) - unpaidBreaks
+		if workMinutes < 0 {
+			workMinutes = 0
+		}
+		summary.WorkMinutes += workMinutes
+		summary.BreakMinutes += shiftBreaks
+		summary.UnpaidBreakMinutes += unpaidBreaks
+	}
+
+	if !summary.Open && len(shifts) > 0 {
+		summary.LastClockOut = shifts[len(shifts)-1].ClockOut
+	}
+	return summary
+}
@@ -0,0 +1,280 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/qrauth"
+	"github.com/aoncodev/qrbackend/shiftstate"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxSyncEvents = 500
+
+// SyncEvent is one scan a kiosk queued while offline
+type SyncEvent struct {
+	ClientEventID string    `json:"client_event_id"`
+	Type          string    `json:"type"` // "clock_in", "clock_out", "break_start", "break_end"
+	EmployeeID    uint      `json:"employee_id"`
+	QRID          string    `json:"qr_id"`
+	BreakType     string    `json:"break_type"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+// SyncEventResult reports what happened to one event
+type SyncEventResult struct {
+	Index         int    `json:"index"`
+	ClientEventID string `json:"client_event_id,omitempty"`
+	Status        string `json:"status"` // "applied", "conflict", "rejected", "failed"
+	HTTPStatus    int    `json:"http_status"`
+	Error         string `json:"error,omitempty"`
+	AttendanceID  uint   `json:"attendance_id,omitempty"`
+	BreakID       uint   `json:"break_id,omitempty"`
+	Replayed      bool   `json:"replayed,omitempty"`
+}
+
+// syncMaxAge limits how far back queued events may be, from SYNC_MAX_AGE (default 72h)
+func syncMaxAge() time.Duration {
+	age, err := time.ParseDuration(os.Getenv("SYNC_MAX_AGE"))
+	if err != nil || age <= 0 {
+		return 72 * time.Hour
+	}
+	return age
+}
+
+// applySyncEvent replays one event through the same clock actions the
+// individual endpoints use, with the device timestamp as the event time
+func applySyncEvent(event SyncEvent) SyncEventResult {
+	result := SyncEventResult{ClientEventID: event.ClientEventID}
+	fail := func(status int, message string) SyncEventResult {
+		result.HTTPStatus = status
+		result.Error = message
+		result.Status = "rejected"
+		if status == http.StatusConflict {
+			result.Status = "conflict"
+		} else if status >= http.StatusInternalServerError {
+			result.Status = "failed"
+		}
+		return result
+	}
+
+	if event.EmployeeID == 0 || event.OccurredAt.IsZero() {
+		return fail(http.StatusBadRequest, "employee_id and occurred_at are required")
+	}
+	now := time.Now()
+	if event.OccurredAt.After(now.Add(2 * time.Minute)) {
+		return fail(http.StatusBadRequest, "occurred_at is in the future")
+	}
+	if now.Sub(event.OccurredAt) > syncMaxAge() {
+		return fail(http.StatusBadRequest, "occurred_at is too old to sync")
+	}
+
+	// Offline scans need a QR code just like the online clock endpoints
+	if event.QRID == "" {
+		if qrRequiredOnClock() {
+			return fail(http.StatusBadRequest, "qr_id is required")
+		}
+	} else {
+		employee, err := initializers.QRVerifier.Verify(event.QRID)
+		if err != nil {
+			if err == qrauth.ErrEmployeeNotFound {
+				return fail(http.StatusNotFound, "Employee not found")
+			}
+			return fail(http.StatusUnauthorized, "Invalid QR code")
+		}
+		if employee.ID != event.EmployeeID {
+			return fail(http.StatusForbidden, "QR code does not match employee")
+		}
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		switch shiftstate.Action(event.Type) {
+		case shiftstate.ClockIn:
+			attendance, err := clockInTx(tx, event.EmployeeID, event.OccurredAt)
+			if err == nil {
+				result.AttendanceID = attendance.ID
+			}
+			return err
+		case shiftstate.ClockOut:
+			attendance, err := clockOutTx(tx, event.EmployeeID, event.OccurredAt)
+			if err == nil {
+				result.AttendanceID = attendance.ID
+			}
+			return err
+		case shiftstate.StartBreak, shiftstate.EndBreak:
+			if _, err := lockEmployee(tx, event.EmployeeID); err != nil {
+				return err
+			}
+			state, err := loadEmployeeState(tx, event.EmployeeID, event.OccurredAt)
+			if err != nil {
+				return err
+			}
+			if state.Shift == nil {
+				return checkTransition(state, shiftstate.Action(event.Type))
+			}
+			attendanceID := state.Shift.ID
+			result.AttendanceID = attendanceID
+
+			var breakLog *models.BreakLog
+			if shiftstate.Action(event.Type) == shiftstate.StartBreak {
+				if event.BreakType == "" {
+					return rejectClock(http.StatusBadRequest, "break_type is required")
+				}
+				breakLog, err = startBreakTx(tx, attendanceID, event.BreakType, event.OccurredAt)
+			} else {
+				breakLog, err = endBreakTx(tx, attendanceID, event.OccurredAt)
+			}
+			if err == nil {
+				result.BreakID = breakLog.ID
+			}
+			return err
+		default:
+			return rejectClock(http.StatusBadRequest, fmt.Sprintf("unknown event type %q", event.Type))
+		}
+	})
+
+	var ce *clockError
+	switch {
+	case err == nil:
+		result.Status = "applied"
+		result.HTTPStatus = http.StatusOK
+		return result
+	case errors.As(err, &ce):
+		return fail(ce.Status, ce.Message)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return fail(http.StatusConflict, "Conflicting request already in progress")
+	default:
+		return fail(http.StatusInternalServerError, "Failed to apply event")
+	}
+}
+
+// syncEventResult applies one event and records its result under the
+// event's client_event_id, or answers from the recorded result when the
+// kiosk uploads the same event again. The ID is hashed into the key so any
+// length fits the key column, and reusing it for a different event is a
+// conflict.
+func syncEventResult(kioskID uint, event SyncEvent) SyncEventResult {
+	if event.ClientEventID == "" {
+		return applySyncEvent(event)
+	}
+	failed := func(message string) SyncEventResult {
+		return SyncEventResult{
+			ClientEventID: event.ClientEventID,
+			Status:        "failed",
+			HTTPStatus:    http.StatusInternalServerError,
+			Error:         message,
+		}
+	}
+
+	keySum := sha256.Sum256([]byte(fmt.Sprintf("SYNC kiosk:%d %s", kioskID, event.ClientEventID)))
+	key := hex.EncodeToString(keySum[:])
+	eventJSON, _ := json.Marshal(event)
+	sum := sha256.Sum256(eventJSON)
+	requestHash := hex.EncodeToString(sum[:])
+
+	var stored models.IdempotencyKey
+	err := initializers.DB.Where("key = ? AND expires_at > ?", key, time.Now()).First(&stored).Error
+	switch {
+	case err == nil:
+		if stored.RequestHash != requestHash {
+			return SyncEventResult{
+				ClientEventID: event.ClientEventID,
+				Status:        "conflict",
+				HTTPStatus:    http.StatusConflict,
+				Error:         "client_event_id was already used for a different event",
+			}
+		}
+		var result SyncEventResult
+		if err := json.Unmarshal(stored.ResponseBody, &result); err != nil {
+			return failed("Failed to read the recorded result")
+		}
+		result.Replayed = true
+		return result
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return failed("Failed to check client_event_id")
+	}
+
+	result := applySyncEvent(event)
+
+	// Server errors are left unrecorded so the kiosk can retry them
+	if result.Status == "failed" {
+		return result
+	}
+
+	body, _ := json.Marshal(result)
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		// Drop an expired record of the same ID
+		if err := tx.Where("key = ?", key).Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.IdempotencyKey{
+			Key:          key,
+			RequestHash:  requestHash,
+			StatusCode:   result.HTTPStatus,
+			ResponseBody: body,
+			ContentType:  "application/json",
+			ExpiresAt:    time.Now().Add(syncMaxAge() + 24*time.Hour),
+		}).Error
+	})
+	if err != nil {
+		// The event is applied either way; a re-upload is then checked
+		// against the shift state instead of the recorded result
+		log.Printf("recording sync event %q of kiosk %d failed: %v", event.ClientEventID, kioskID, err)
+	}
+	return result
+}
+
+// SyncKioskEvents applies a batch of events a kiosk queued while offline,
+// in order, and returns a result per event. Events carrying a
+// client_event_id are recorded so a re-upload of the same batch is answered
+// from the stored results instead of being applied twice.
+func SyncKioskEvents(c *gin.Context) {
+	kiosk, ok := authenticateKiosk(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Events []SyncEvent `json:"events" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "events are required"})
+		return
+	}
+	if len(req.Events) > maxSyncEvents {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("at most %d events per batch", maxSyncEvents)})
+		return
+	}
+
+	results := make([]SyncEventResult, 0, len(req.Events))
+	applied, rejected := 0, 0
+
+	for i, event := range req.Events {
+		result := syncEventResult(kiosk.ID, event)
+		result.Index = i
+		if result.Status == "applied" {
+			applied++
+		} else {
+			rejected++
+		}
+		results = append(results, result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"kiosk_id": kiosk.ID,
+		"applied":  applied,
+		"rejected": rejected,
+		"results":  results,
+	})
+}
@@ -2,11 +2,16 @@ package controllers
 
 import (
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/aoncodev/qrbackend/initializers"
 	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/payroll"
+	"github.com/aoncodev/qrbackend/qrauth"
+	"github.com/aoncodev/qrbackend/utils"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type EmployeeStatusRequest struct {
@@ -14,9 +19,13 @@ type EmployeeStatusRequest struct {
 }
 
 type CurrentBreak struct {
-	ID         uint   `json:"id"`
-	BreakType  string `json:"break_type"`
-	BreakStart string `json:"break_start"` // ISO8601 string
+	ID             uint    `json:"id"`
+	BreakType      string  `json:"break_type"`
+	BreakStart     string  `json:"break_start"`      // ISO8601 string
+	AllowedMinutes int     `json:"allowed_minutes"`  // 0 = no limit
+	DueAt          *string `json:"due_at,omitempty"` // ISO8601 string
+	Overdue        bool    `json:"overdue"`
+	OverdueMinutes int     `json:"overdue_minutes"`
 }
 
 type EmployeeStatusResponse struct {
@@ -28,7 +37,72 @@ type EmployeeStatusResponse struct {
 	CurrentBreak         *CurrentBreak `json:"current_break,omitempty"`
 }
 
+// verifyQRCode resolves a scanned code to an employee, writing the error
+// response itself when the code is rejected.
+func verifyQRCode(c *gin.Context, code string) (*models.Employee, bool) {
+	employee, err := initializers.QRVerifier.Verify(code)
+	if err == nil {
+		if !employee.Active {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Employee is deactivated"})
+			return nil, false
+		}
+		return employee, true
+	}
+
+	switch err {
+	case qrauth.ErrEmployeeNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+	case qrauth.ErrExpiredCode:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "QR code has expired"})
+	case qrauth.ErrRevokedCode:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "QR code has been revoked"})
+	default:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid QR code"})
+	}
+	return nil, false
+}
+
+// qrRequiredOnClock reports whether clock actions must carry a scanned QR
+// code, which they do unless QR_REQUIRE_ON_CLOCK=false
+func qrRequiredOnClock() bool {
+	return os.Getenv("QR_REQUIRE_ON_CLOCK") != "false"
+}
+
+// loadClockEmployee loads the employee for the clock endpoints. The qr_id
+// query param must verify and belong to the same employee; it may only be
+// left out when QR_REQUIRE_ON_CLOCK=false.
+func loadClockEmployee(c *gin.Context) (*models.Employee, bool) {
+	employeeID := c.Query("employee_id")
+	if employeeID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "employee_id is required"})
+		return nil, false
+	}
+
+	var employee models.Employee
+	if err := initializers.DB.First(&employee, employeeID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return nil, false
+	}
+
+	code := c.Query("qr_id")
+	if code == "" {
+		if qrRequiredOnClock() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "qr_id is required"})
+			return nil, false
+		}
+		return &employee, true
+	}
 
+	scanned, ok := verifyQRCode(c, code)
+	if !ok {
+		return nil, false
+	}
+	if scanned.ID != employee.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "QR code does not match employee"})
+		return nil, false
+	}
+	return &employee, true
+}
 
 func GetEmployeeStatus(c *gin.Context) {
 	var req EmployeeStatusRequest
@@ -37,54 +111,52 @@ func GetEmployeeStatus(c *gin.Context) {
 		return
 	}
 
-	var employee models.Employee
-	if err := initializers.DB.Where("qr_id = ?", req.QRID).First(&employee).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+	employee, ok := verifyQRCode(c, req.QRID)
+	if !ok {
 		return
 	}
 
-	var attendance models.AttendanceLog
-	err := initializers.DB.
-		Where("employee_id = ? AND clock_out IS NULL", employee.ID).
-		Order("created_at DESC").
-		First(&attendance).Error
-
+	state, err := loadEmployeeState(initializers.DB, employee.ID, time.Now())
 	if err != nil {
-		// No attendance found — not clocked in yet
-		c.JSON(http.StatusOK, EmployeeStatusResponse{
-			EmployeeID:   employee.ID,
-			EmployeeName: employee.Name,
-			Status:       "not_clocked_in",
-		})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load attendance"})
 		return
 	}
 
-	var breakLog models.BreakLog
-	breakErr := initializers.DB.
-		Where("attendance_id = ? AND break_end IS NULL", attendance.ID).
-		Order("created_at DESC").
-		First(&breakLog).Error
-
-	var status string = "working"
-	var currentBreak * CurrentBreak = nil
-	if breakErr == nil {
-		status = "on_break"
-		currentBreak = &CurrentBreak{
-			ID:         breakLog.ID,
-			BreakType:  breakLog.BreakType,
-			BreakStart: breakLog.BreakStart.Format(time.RFC3339),
+	response := EmployeeStatusResponse{
+		EmployeeID:   employee.ID,
+		EmployeeName: employee.Name,
+		Status:       string(state.State),
+	}
+
+	if state.Shift != nil {
+		clockInStr := state.Shift.ClockIn.Format(time.RFC3339)
+		response.CurrentAttendanceID = &state.Shift.ID
+		response.ClockInTime = &clockInStr
+	}
+
+	if state.Break != nil {
+		catalog, err := loadBreakCatalog(initializers.DB)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load break types"})
+			return
+		}
+		check := catalog.Check(*state.Break, time.Now())
+
+		response.CurrentBreak = &CurrentBreak{
+			ID:             state.Break.ID,
+			BreakType:      state.Break.BreakType,
+			BreakStart:     state.Break.BreakStart.Format(time.RFC3339),
+			AllowedMinutes: check.AllowedMinutes,
+			Overdue:        check.Overdue,
+			OverdueMinutes: check.OverdueMinutes,
+		}
+		if check.DueAt != nil {
+			dueAt := check.DueAt.Format(time.RFC3339)
+			response.CurrentBreak.DueAt = &dueAt
 		}
 	}
 
-	clockInStr := attendance.ClockIn.Format(time.RFC3339)
-	c.JSON(http.StatusOK, EmployeeStatusResponse{
-		EmployeeID:          employee.ID,
-		EmployeeName:        employee.Name,
-		Status:              status,
-		CurrentAttendanceID: &attendance.ID,
-		ClockInTime:         &clockInStr,
-		CurrentBreak:        currentBreak,
-	})
+	c.JSON(http.StatusOK, response)
 }
 
 
@@ -98,16 +170,24 @@ func EmployeeLogin(c *gin.Context) {
 		return
 	}
 
-	var employee models.Employee
-	if err := initializers.DB.Where("qr_id = ?", req.QRID).First(&employee).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+	employee, ok := verifyQRCode(c, req.QRID)
+	if !ok {
+		return
+	}
+
+	// Badge logins always get an employee token, even for admins; admin
+	// access still requires the OTP login
+	accessToken, err := utils.GenerateJWT(employee.ID, "employee")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate access token"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"id":   employee.ID,
-		"name": employee.Name,
-		"role": employee.Role,
+		"id":           employee.ID,
+		"name":         employee.Name,
+		"role":         employee.Role,
+		"access_token": accessToken,
 	})
 }
 
@@ -121,60 +201,86 @@ func GetEmployeeStatusByID(c *gin.Context) {
 		return
 	}
 
-	// Load today’s attendance (whether or not it's clocked out)
-	var attendance models.AttendanceLog
-	err := initializers.DB.
-		Where("employee_id = ? AND DATE(clock_in) = ?", employee.ID, time.Now().UTC().Format("2006-01-02")).
+	// Load every shift of the current business day (whether or not it's clocked out)
+	var shifts []models.AttendanceLog
+	if err := initializers.DB.
+		Where("employee_id = ? AND business_date = ?", employee.ID, utils.BusinessDate(time.Now())).
+		Order("clock_in").
 		Preload("Breaks").
-		First(&attendance).Error
+		Find(&shifts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load attendance"})
+		return
+	}
 
-	if err != nil {
+	if len(shifts) == 0 {
 		// No attendance today at all
 		c.JSON(http.StatusOK, gin.H{
 			"attendance": nil,
 			"breaks":     nil,
+			"shifts":     []models.AttendanceLog{},
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"attendance": attendance,
-		"breaks":     attendance.Breaks,
-	})
-}
-
-func ClockIn(c *gin.Context) {
-	employeeID := c.Query("employee_id")
-	if employeeID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "employee_id is required"})
+	// Hours are worked out like the reports and payroll do
+	rules, err := loadPayRules(initializers.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load pay rules"})
 		return
 	}
+	catalog := rules.Breaks
 
-	var employee models.Employee
-	if err := initializers.DB.First(&employee, employeeID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
-		return
+	// Breaks today that ran, or are running, past their limit
+	now := time.Now()
+	overdueBreaks := []gin.H{}
+	for _, shift := range shifts {
+		for _, b := range shift.Breaks {
+			if check := catalog.Check(b, now); check.Overdue {
+				overdueBreaks = append(overdueBreaks, gin.H{
+					"break_id":        b.ID,
+					"break_type":      b.BreakType,
+					"open":            b.BreakEnd == nil,
+					"allowed_minutes": check.AllowedMinutes,
+					"due_at":          check.DueAt,
+					"overdue_minutes": check.OverdueMinutes,
+				})
+			}
+		}
 	}
 
-	// Enforce one shift per day: check if clock-in already exists today
-	var existing models.AttendanceLog
-	err := initializers.DB.
-		Where("employee_id = ? AND DATE(clock_in) = ?", employee.ID, time.Now().UTC().Format("2006-01-02")).
-		First(&existing).Error
+	// "attendance"/"breaks" describe the latest shift; "shifts" has them all
+	summary := summarizeShifts(shifts, now, rules)
+	latest := shifts[len(shifts)-1]
+	c.JSON(http.StatusOK, gin.H{
+		"attendance":         latest,
+		"breaks":             latest.Breaks,
+		"shifts":             shifts,
+		"overdue_breaks":     overdueBreaks,
+		"total_worked_hours": float64(summary.WorkMinutes) / 60.0,
+		"total_break_hours":  float64(summary.BreakMinutes) / 60.0,
+	})
+}
 
-	if err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "You have already clocked in today"})
+func ClockIn(c *gin.Context) {
+	employee, ok := loadClockEmployee(c)
+	if !ok {
 		return
 	}
 
-	// Create new attendance log
-	newAttendance := models.AttendanceLog{
-		EmployeeID: employee.ID,
-		ClockIn:    time.Now(),
-	}
+	clockInEmployee(c, employee)
+}
 
-	if err := initializers.DB.Create(&newAttendance).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clock in"})
+// clockInEmployee opens a new attendance log for an already identified employee
+func clockInEmployee(c *gin.Context, employee *models.Employee) {
+	var newAttendance *models.AttendanceLog
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		newAttendance, err = clockInTx(tx, employee.ID, time.Now())
+		return err
+	})
+
+	if err != nil {
+		respondClockError(c, err, "Failed to clock in")
 		return
 	}
 
@@ -188,47 +294,20 @@ func ClockIn(c *gin.Context) {
 
 
 func ClockOut(c *gin.Context) {
-	employeeID := c.Query("employee_id")
-	if employeeID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "employee_id is required"})
-		return
-	}
-
-	var employee models.Employee
-	if err := initializers.DB.First(&employee, employeeID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+	employee, ok := loadClockEmployee(c)
+	if !ok {
 		return
 	}
 
-	// Find open attendance log
-	var attendance models.AttendanceLog
-	err := initializers.DB.
-		Where("employee_id = ? AND clock_out IS NULL", employee.ID).
-		Order("created_at DESC").
-		First(&attendance).Error
+	var attendance *models.AttendanceLog
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		attendance, err = clockOutTx(tx, employee.ID, time.Now())
+		return err
+	})
 
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No active attendance log found"})
-		return
-	}
-
-	// Check if currently on a break
-	var activeBreak models.BreakLog
-	err = initializers.DB.
-		Where("attendance_id = ? AND break_end IS NULL", attendance.ID).
-		First(&activeBreak).Error
-
-	if err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "You must end your break before clocking out"})
-		return
-	}
-
-	// Clock out
-	now := time.Now()
-	attendance.ClockOut = &now
-
-	if err := initializers.DB.Save(&attendance).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clock out"})
+		respondClockError(c, err, "Failed to clock out")
 		return
 	}
 
@@ -251,31 +330,15 @@ func StartBreak(c *gin.Context) {
 		return
 	}
 
-	// Confirm attendance exists
-	var attendance models.AttendanceLog
-	if err := initializers.DB.First(&attendance, req.AttendanceID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Attendance log not found"})
-		return
-	}
-
-	// Check if already on a break
-	var activeBreak models.BreakLog
-	if err := initializers.DB.
-		Where("attendance_id = ? AND break_end IS NULL", req.AttendanceID).
-		First(&activeBreak).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "You must end your current break before starting a new one"})
-		return
-	}
-
-	// Create new break
-	newBreak := models.BreakLog{
-		AttendanceID: req.AttendanceID,
-		BreakType:    req.BreakType,
-		BreakStart:   time.Now(),
-	}
+	var newBreak *models.BreakLog
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		newBreak, err = startBreakTx(tx, req.AttendanceID, req.BreakType, time.Now())
+		return err
+	})
 
-	if err := initializers.DB.Create(&newBreak).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start break"})
+	if err != nil {
+		respondClockError(c, err, "Failed to start break")
 		return
 	}
 
@@ -297,21 +360,15 @@ func EndBreak(c *gin.Context) {
 		return
 	}
 
-	// Check for open break
-	var breakLog models.BreakLog
-	if err := initializers.DB.
-		Where("attendance_id = ? AND break_end IS NULL", req.AttendanceID).
-		Order("created_at DESC").
-		First(&breakLog).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No active break found"})
-		return
-	}
-
-	now := time.Now()
-	breakLog.BreakEnd = &now
+	var breakLog *models.BreakLog
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		breakLog, err = endBreakTx(tx, req.AttendanceID, time.Now())
+		return err
+	})
 
-	if err := initializers.DB.Save(&breakLog).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end break"})
+	if err != nil {
+		respondClockError(c, err, "Failed to end break")
 		return
 	}
 
@@ -333,6 +390,15 @@ func GetEmployeeReports(c *gin.Context) {
 		return
 	}
 
+	if _, err := utils.ParseBusinessDate(startDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start_date format, use YYYY-MM-DD"})
+		return
+	}
+	if _, err := utils.ParseBusinessDate(endDate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end_date format, use YYYY-MM-DD"})
+		return
+	}
+
 	var employee models.Employee
 	if err := initializers.DB.First(&employee, employeeID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
@@ -341,82 +407,161 @@ func GetEmployeeReports(c *gin.Context) {
 
 	var attendanceLogs []models.AttendanceLog
 	if err := initializers.DB.
-		Where("employee_id = ? AND DATE(clock_in) BETWEEN ? AND ?", employee.ID, startDate, endDate).
+		Where("employee_id = ? AND business_date BETWEEN ? AND ?", employee.ID, startDate, endDate).
+		Order("clock_in").
 		Preload("Breaks").
 		Find(&attendanceLogs).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load attendance logs"})
 		return
 	}
 
-	layout := "15:04"
-	scheduledStart, _ := time.Parse(layout, employee.StartTime)
-	reports := []gin.H{}
-
+	// Group completed shifts by the business day they started on
+	days := []string{}
+	shiftsByDay := map[string][]models.AttendanceLog{}
 	for _, log := range attendanceLogs {
 		if log.ClockOut == nil {
 			continue // skip incomplete shifts
 		}
+		if _, seen := shiftsByDay[log.BusinessDate]; !seen {
+			days = append(days, log.BusinessDate)
+		}
+		shiftsByDay[log.BusinessDate] = append(shiftsByDay[log.BusinessDate], log)
+	}
 
-		// Parse scheduled start for that day
-		scheduled := time.Date(log.ClockIn.Year(), log.ClockIn.Month(), log.ClockIn.Day(),
-			scheduledStart.Hour(), scheduledStart.Minute(), 0, 0, log.ClockIn.Location())
+	wages, err := loadWageHistory(initializers.DB, employee)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load wage history"})
+		return
+	}
 
-		lateMinutes := 0
-		if log.ClockIn.After(scheduled) {
-			lateMinutes = int(log.ClockIn.Sub(scheduled).Minutes())
-		}
+	schedule, err := loadEmployeeSchedule(initializers.DB, employee, startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load schedule"})
+		return
+	}
+
+	rules, err := loadPayRules(initializers.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load pay rules"})
+		return
+	}
+
+	breakRules, err := loadBreakRules(initializers.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load break rules"})
+		return
+	}
+
+	// Price the completed shifts, with the earlier days of the first week
+	// so weekly overtime is counted from Monday
+	calc, err := newPayCalculator(initializers.DB, wages)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load pay rules"})
+		return
+	}
+	leadIn, err := loadWeekLeadIn(initializers.DB, employee.ID, startDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load attendance logs"})
+		return
+	}
+	segmentsByDay := map[string][]payroll.Segment{}
+	for _, segment := range priceShifts(calc, append(leadIn, attendanceLogs...), rules) {
+		segmentsByDay[segment.Date] = append(segmentsByDay[segment.Date], segment)
+	}
+
+	reports := []gin.H{}
+
+	for _, day := range days {
+		shifts := shiftsByDay[day]
+		summary := summarizeShifts(shifts, time.Now(), rules)
+
+		// Lateness is measured on the first shift of the day and early
+		// leave/overtime on the last; work on an unscheduled day is never late
+		scheduledDay := schedule.On(day)
+		timing := measurePunctuality(rules.Policy, scheduledDay, day, summary.FirstClockIn, summary.LastClockOut)
 
 		breakSummary := map[string]struct {
 			Duration int
+			Unpaid   int
 			Count    int
 		}{}
-		totalBreakMinutes := 0
-
-		for _, b := range log.Breaks {
-			if b.BreakEnd == nil {
-				continue
+		shiftRows := []gin.H{}
+		violations := []gin.H{}
+
+		for _, log := range shifts {
+			if violation := checkShiftCompliance(breakRules, log); violation != nil {
+				violations = append(violations, gin.H{
+					"attendance_id": log.ID,
+					"violation":     violation,
+				})
 			}
-			duration := int(b.BreakEnd.Sub(b.BreakStart).Minutes())
-			summary := breakSummary[b.BreakType]
-			summary.Duration += duration
-			summary.Count++
-			breakSummary[b.BreakType] = summary
-			totalBreakMinutes += duration
+			for _, b := range log.Breaks {
+				if b.BreakEnd == nil {
+					continue
+				}
+				duration := utils.RoundMinutes(int(b.BreakEnd.Sub(b.BreakStart).Minutes()), rules.Policy.BreakRoundingMinutes, rules.Policy.BreakRounding)
+				summary := breakSummary[b.BreakType]
+				summary.Duration += duration
+				summary.Unpaid += rules.Breaks.UnpaidMinutes(b.BreakType, duration)
+				summary.Count++
+				breakSummary[b.BreakType] = summary
+			}
+			shiftRows = append(shiftRows, gin.H{
+				"attendance_id": log.ID,
+				"clock_in":      utils.InBusinessTime(log.ClockIn),
+				"clock_out":     utils.InBusinessTime(*log.ClockOut),
+			})
 		}
 
-		workMinutes := int(log.ClockOut.Sub(log.ClockIn).Minutes()) - totalBreakMinutes
-		workHours := float64(workMinutes) / 60.0
-		breakHours := float64(totalBreakMinutes) / 60.0
-		totalHours := float64(workMinutes+totalBreakMinutes) / 60.0
-		totalWage := workHours * float64(employee.HourlyWage)
+		workHours := float64(summary.WorkMinutes) / 60.0
+		breakHours := float64(summary.BreakMinutes) / 60.0
+		unpaidBreakHours := float64(summary.UnpaidBreakMinutes) / 60.0
+		totalHours := float64(summary.WorkMinutes+summary.UnpaidBreakMinutes) / 60.0
+		hourlyWage := wages.RateOn(day)
+		pay := payroll.SumSegments(segmentsByDay[day])
 
 		breaks := []gin.H{}
 		for breakType, info := range breakSummary {
 			breaks = append(breaks, gin.H{
 				"break_type":       breakType,
+				"paid":             rules.Breaks[breakType].Paid,
 				"duration_minutes": info.Duration,
+				"unpaid_minutes":   info.Unpaid,
 				"count":            info.Count,
 			})
 		}
 
-		// Convert to local time for frontend display
-		localClockIn := log.ClockIn.Local()
-		localClockOut := log.ClockOut.Local()
-		
+		// Convert to business time for frontend display
+		localClockIn := utils.InBusinessTime(summary.FirstClockIn)
+		localClockOut := utils.InBusinessTime(*summary.LastClockOut)
+
 		reports = append(reports, gin.H{
-			"date":               localClockIn.Format("2006-01-02"),
-			"clock_in":           localClockIn,
-			"clock_out":          localClockOut,
-			"breaks":             breaks,
-			"total_worked_hours": workHours,
-			"total_break_hours":  breakHours,
-			"total_hours":        totalHours,
-			"hourly_wage":        employee.HourlyWage,
-			"total_wage":         totalWage,
-			"late_minutes":       lateMinutes,
-			"is_late":            lateMinutes > 0,
+			"date":                  day,
+			"iso_week":              isoWeek(day),
+			"clock_in":              localClockIn,
+			"clock_out":             localClockOut,
+			"shifts":                shiftRows,
+			"breaks":                breaks,
+			"total_worked_hours":    workHours,
+			"total_break_hours":     breakHours,
+			"unpaid_break_hours":    unpaidBreakHours,
+			"total_hours":           totalHours,
+			"hourly_wage":           hourlyWage,
+			"base_wage":             pay.BaseWage,
+			"premium_wage":          pay.PremiumWage,
+			"total_wage":            pay.Wage,
+			"paid_overtime_hours":   pay.OvertimeMinutes / 60.0,
+			"night_hours":           pay.NightMinutes / 60.0,
+			"holiday_hours":         pay.HolidayMinutes / 60.0,
+			"pay_segments":          segmentsByDay[day],
+			"scheduled":             scheduledDay.Scheduled,
+			"late_minutes":          timing.LateMinutes,
+			"is_late":               timing.LateMinutes > 0,
+			"early_leave_minutes":   timing.EarlyLeaveMinutes,
+			"overtime_minutes":      timing.OvertimeMinutes,
+			"compliance_violations": violations,
 		})
 	}
 
 	c.JSON(http.StatusOK, reports)
-}
+}
\ No newline at end of file
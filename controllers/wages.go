@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// wageHistory is an employee's rates ordered by effective date
+type wageHistory struct {
+	rates    []models.WageRate
+	fallback int // Employee.HourlyWage, for employees without any history
+}
+
+func loadWageHistory(db *gorm.DB, employee models.Employee) (wageHistory, error) {
+	history := wageHistory{fallback: employee.HourlyWage}
+	err := db.Where("employee_id = ?", employee.ID).Order("effective_from").Find(&history.rates).Error
+	return history, err
+}
+
+// RateOn returns the hourly wage effective on a business date. Dates before
+// the first recorded rate use that first rate.
+func (h wageHistory) RateOn(date string) int {
+	if len(h.rates) == 0 {
+		return h.fallback
+	}
+	i := sort.Search(len(h.rates), func(i int) bool { return h.rates[i].EffectiveFrom > date })
+	if i == 0 {
+		return h.rates[0].HourlyWage
+	}
+	return h.rates[i-1].HourlyWage
+}
+
+// withCurrentWages sets each employee's HourlyWage to the rate effective
+// today. The stored column is only the starting wage.
+func withCurrentWages(db *gorm.DB, employees []models.Employee) error {
+	if len(employees) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(employees))
+	for _, e := range employees {
+		ids = append(ids, e.ID)
+	}
+
+	var rates []models.WageRate
+	if err := db.Where("employee_id IN ?", ids).Order("effective_from").Find(&rates).Error; err != nil {
+		return err
+	}
+	ratesByEmployee := map[uint][]models.WageRate{}
+	for _, r := range rates {
+		ratesByEmployee[r.EmployeeID] = append(ratesByEmployee[r.EmployeeID], r)
+	}
+
+	today := utils.BusinessDate(time.Now())
+	for i := range employees {
+		history := wageHistory{rates: ratesByEmployee[employees[i].ID], fallback: employees[i].HourlyWage}
+		employees[i].HourlyWage = history.RateOn(today)
+	}
+	return nil
+}
+
+// withCurrentWage is withCurrentWages for one employee
+func withCurrentWage(db *gorm.DB, employee *models.Employee) error {
+	employees := []models.Employee{*employee}
+	if err := withCurrentWages(db, employees); err != nil {
+		return err
+	}
+	*employee = employees[0]
+	return nil
+}
+
+// appendWageRate records a new rate; a rate for the same date replaces the
+// old one. The employee's HourlyWage is set to the rate effective today for
+// the response only, since a stored copy would go stale when a future rate
+// takes effect.
+func appendWageRate(tx *gorm.DB, employee *models.Employee, hourlyWage int, effectiveFrom string, actorID *uint) (*models.WageRate, error) {
+	rate := models.WageRate{
+		EmployeeID:    employee.ID,
+		HourlyWage:    hourlyWage,
+		EffectiveFrom: effectiveFrom,
+		CreatedBy:     actorID,
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "employee_id"}, {Name: "effective_from"}},
+		DoUpdates: clause.AssignmentColumns([]string{"hourly_wage", "created_by"}),
+	}).Create(&rate).Error; err != nil {
+		return nil, err
+	}
+
+	history, err := loadWageHistory(tx, *employee)
+	if err != nil {
+		return nil, err
+	}
+	employee.HourlyWage = history.RateOn(utils.BusinessDate(time.Now()))
+
+	if err := recordAudit(tx, actorID, "wage.append", "employee", employee.ID, nil, rate); err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// GetEmployeeWageHistory lists an employee's wage rates, oldest first
+func GetEmployeeWageHistory(c *gin.Context) {
+	id := c.Param("id")
+	var employee models.Employee
+
+	if err := initializers.DB.First(&employee, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	history, err := loadWageHistory(initializers.DB, employee)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wage history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"employee_id":  employee.ID,
+		"current_wage": history.RateOn(utils.BusinessDate(time.Now())),
+		"rates":        history.rates,
+	})
+}
+
+// AddEmployeeWageRate appends a rate, e.g. a raise that starts next month
+func AddEmployeeWageRate(c *gin.Context) {
+	id := c.Param("id")
+	var employee models.Employee
+
+	if err := initializers.DB.First(&employee, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Employee not found"})
+		return
+	}
+
+	var req struct {
+		HourlyWage    int    `json:"hourly_wage" binding:"required"`
+		EffectiveFrom string `json:"effective_from" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil || req.HourlyWage <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "hourly_wage and effective_from are required"})
+		return
+	}
+	if _, err := utils.ParseBusinessDate(req.EffectiveFrom); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid effective_from format, use YYYY-MM-DD"})
+		return
+	}
+
+	var rate *models.WageRate
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		rate, err = appendWageRate(tx, &employee, req.HourlyWage, req.EffectiveFrom, auditActor(c))
+		return err
+	})
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add wage rate"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"rate":     rate,
+		"employee": employee,
+	})
+}
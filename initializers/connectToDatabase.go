@@ -13,7 +13,10 @@ var DB *gorm.DB
 func ConnectToDatabase() {
 	var err error
 	dsn := os.Getenv("DB_URL")
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+		// Report unique index violations as gorm.ErrDuplicatedKey
+		TranslateError: true,
+	})
 
 	if err != nil {
 		log.Fatalf("failed to connect to database: %v", err)
@@ -0,0 +1,9 @@
+package initializers
+
+import "github.com/aoncodev/qrbackend/notify"
+
+var Notifier notify.Notifier
+
+func LoadNotifier() {
+	Notifier = notify.NewFromEnv()
+}
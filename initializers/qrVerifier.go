@@ -0,0 +1,9 @@
+package initializers
+
+import "github.com/aoncodev/qrbackend/qrauth"
+
+var QRVerifier qrauth.Verifier
+
+func LoadQRVerifier() {
+	QRVerifier = qrauth.NewFromEnv(DB)
+}
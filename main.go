@@ -14,18 +14,24 @@ import (
 func init() {
 	initializers.LoadEnvVariables()
 	initializers.ConnectToDatabase()
+	initializers.LoadQRVerifier()
+	initializers.LoadNotifier()
 }
 
 
 func main() {
 	r := gin.Default()
 
+	go middleware.CleanupIdempotencyKeys(time.Hour)
+	go controllers.RunAutoClockOut(5 * time.Minute)
+	go controllers.RunBreakOverrunMonitor(time.Minute)
+
 	// CORS configuration - allow both development and production origins
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:3000", "https://qrbackend-doo3.onrender.com", "https://www.qrbackend-doo3.onrender.com", "https://employee-clock-frontend.vercel.app", "https://www.employee-clock-frontend.vercel.app","https://admin-frontend-attendance.vercel.app" },
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Kiosk-Key", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Content-Length", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge: 12 * time.Hour,
 	}))
@@ -35,29 +41,96 @@ func main() {
 	r.POST("/api/employee/status", controllers.GetEmployeeStatus)
 	r.POST("/api/employee/login", controllers.EmployeeLogin)
 	r.GET("/api/employee/status/:id", controllers.GetEmployeeStatusByID)
-	r.POST("/api/employee/clock-in", controllers.ClockIn)
-	r.POST("/api/employee/clock-out", controllers.ClockOut)
-	r.POST("/api/employee/break/start", controllers.StartBreak)
-	r.POST("/api/employee/break/end", controllers.EndBreak)
+	r.POST("/api/employee/clock-in", middleware.Idempotency(), controllers.ClockIn)
+	r.POST("/api/employee/clock-out", middleware.Idempotency(), controllers.ClockOut)
+	r.POST("/api/employee/break/start", middleware.Idempotency(), controllers.StartBreak)
+	r.POST("/api/employee/break/end", middleware.Idempotency(), controllers.EndBreak)
 	r.GET("/api/attendance/daily", controllers.GetDailyAttendance)
+	r.GET("/api/break-types", controllers.GetBreakTypes)
+	r.GET("/api/kiosk/code", controllers.GetKioskCode)
+	r.POST("/api/kiosk/sync", controllers.SyncKioskEvents)
+
+	// Routes for employees signed in on their own device
+	employee := r.Group("/api/employee")
+	employee.Use(middleware.JWTAuthMiddleware(), middleware.RequireRole("employee"))
+
+	employee.POST("/kiosk/clock-in", middleware.Idempotency(), controllers.KioskClockIn)
+	employee.GET("/corrections", controllers.GetMyCorrectionRequests)
+	employee.POST("/corrections", controllers.CreateCorrectionRequest)
 
 
 
 	admin := r.Group("/api")
-	admin.Use(middleware.JWTAuthMiddleware())
+	admin.Use(middleware.JWTAuthMiddleware(), middleware.RequireRole("admin"))
 
 	admin.GET("/employees", controllers.GetEmployees)
 	admin.GET("/employees/:id", controllers.GetEmployeeByID) // Assuming this is for getting a specific employee
 	admin.POST("/employees", controllers.CreateEmployee)
 	admin.PUT("/employees/:id", controllers.UpdateEmployee)
 	admin.DELETE("/employees/:id", controllers.DeleteEmployee)
+	admin.POST("/employees/:id/restore", controllers.RestoreEmployee)
+	admin.GET("/employees/:id/wages", controllers.GetEmployeeWageHistory)
+	admin.POST("/employees/:id/wages", controllers.AddEmployeeWageRate)
+	admin.GET("/employees/:id/schedule", controllers.GetEmployeeSchedule)
+	admin.PUT("/employees/:id/schedule", controllers.SetEmployeeWeeklySchedule)
+	admin.POST("/employees/:id/schedule/overrides", controllers.SetEmployeeScheduleOverride)
+	admin.DELETE("/employees/:id/schedule/overrides/:date", controllers.DeleteEmployeeScheduleOverride)
+
+	admin.GET("/breaks/overdue", controllers.GetOverdueBreaks)
+	admin.POST("/break-types", controllers.CreateBreakType)
+	admin.PUT("/break-types/:id", controllers.UpdateBreakType)
+	admin.DELETE("/break-types/:id", controllers.DeactivateBreakType)
+
+	admin.GET("/settings/break-rules", controllers.GetBreakRules)
+	admin.PUT("/settings/break-rules", controllers.SetBreakRules)
+	admin.GET("/reports/compliance", controllers.GetComplianceReport)
+
+	admin.GET("/holidays", controllers.GetHolidays)
+	admin.POST("/holidays", controllers.CreateHoliday)
+	admin.POST("/holidays/import", controllers.ImportHolidays)
+	admin.PUT("/holidays/:id", controllers.UpdateHoliday)
+	admin.DELETE("/holidays/:id", controllers.DeleteHoliday)
+
+	admin.GET("/settings/pay-rules", controllers.GetPremiumPayRules)
+	admin.PUT("/settings/pay-rules", controllers.UpdatePremiumPayRules)
+
+	admin.GET("/settings/attendance-policy", controllers.GetAttendancePolicy)
+	admin.PUT("/settings/attendance-policy", controllers.UpdateAttendancePolicy)
+
+	admin.GET("/roster", controllers.GetRoster)
+	admin.POST("/roster", controllers.CreatePlannedShift)
+	admin.POST("/roster/copy-week", controllers.CopyRosterWeek)
+	admin.GET("/roster/compare", controllers.CompareRoster)
+	admin.PUT("/roster/:id", controllers.UpdatePlannedShift)
+	admin.DELETE("/roster/:id", controllers.DeletePlannedShift)
 	admin.GET("/employee/reports", controllers.GetEmployeeReports)
+	admin.GET("/employee/payroll", controllers.GetEmployeePayroll)
+	admin.POST("/employees/:id/qr/rotate", controllers.RotateEmployeeQR)
+	admin.POST("/employees/:id/qr/revoke", controllers.RevokeEmployeeQR)
+	admin.GET("/employees/:id/qr.png", controllers.GetEmployeeQRImage("png"))
+	admin.GET("/employees/:id/qr.svg", controllers.GetEmployeeQRImage("svg"))
+	admin.GET("/employees/badges/templates", controllers.GetBadgeTemplates)
+	admin.POST("/employees/badges/pdf", controllers.ExportBadgeSheet)
+
+	// Kiosk management endpoints
+	admin.GET("/kiosks", controllers.GetKiosks)
+	admin.POST("/kiosks", controllers.CreateKiosk)
+	admin.DELETE("/kiosks/:id", controllers.DeactivateKiosk)
 
 	// Attendance management endpoints
 	admin.PUT("/attendance/:attendance_id", controllers.UpdateAttendance)
 	admin.PUT("/attendance/:attendance_id/breaks", controllers.UpdateAttendanceBreaks)
 	admin.POST("/attendance/:attendance_id/breaks", controllers.AddBreak)
 	admin.DELETE("/attendance/:attendance_id/breaks/:break_id", controllers.DeleteBreak)
+	admin.GET("/attendance/auto-closed", controllers.GetAutoClosedAttendance)
+	admin.POST("/attendance/:attendance_id/review", controllers.ReviewAutoClosedAttendance)
+
+	// Attendance correction requests
+	admin.GET("/corrections", controllers.GetCorrectionRequests)
+	admin.POST("/corrections/:id/approve", controllers.ApproveCorrectionRequest)
+	admin.POST("/corrections/:id/reject", controllers.RejectCorrectionRequest)
+
+	admin.GET("/audit-logs", controllers.GetAuditLogs)
 
 	r.Run(":8080") // listen and serve on localhost:8080
 }
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aoncodev/qrbackend/initializers"
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyTTL is how long a stored response is replayed, from IDEMPOTENCY_TTL (default 24h)
+func IdempotencyTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_TTL"))
+	if err != nil || ttl <= 0 {
+		return 24 * time.Hour
+	}
+	return ttl
+}
+
+// responseRecorder keeps a copy of everything written to the client
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotencyCaller identifies who sent a request so keys from different
+// callers never collide: the logged-in user, else the kiosk, else the
+// employee a public clock request is for
+func idempotencyCaller(c *gin.Context) string {
+	if _, ok := c.Get("userID"); ok {
+		return "user:" + strconv.FormatUint(uint64(c.GetUint("userID")), 10)
+	}
+	if kioskKey := c.GetHeader("X-Kiosk-Key"); kioskKey != "" {
+		sum := sha256.Sum256([]byte(kioskKey))
+		return "kiosk:" + hex.EncodeToString(sum[:])
+	}
+	return "employee:" + c.Query("employee_id")
+}
+
+// Idempotency replays the stored response when a request is retried with
+// the same Idempotency-Key header. Requests without the header pass through.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 200 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys are scoped to the caller and the route, and reusing one for a
+		// different request is an error
+		caller := idempotencyCaller(c)
+		keySum := sha256.Sum256([]byte(caller + "\n" + c.Request.Method + " " + c.FullPath() + "\n" + key))
+		scopedKey := hex.EncodeToString(keySum[:])
+		sum := sha256.Sum256(append([]byte(caller+"\n"+c.Request.URL.RawQuery+"\n"), body...))
+		requestHash := hex.EncodeToString(sum[:])
+
+		now := time.Now()
+		initializers.DB.Where("key = ? AND expires_at <= ?", scopedKey, now).Delete(&models.IdempotencyKey{})
+
+		record := models.IdempotencyKey{
+			Key:         scopedKey,
+			RequestHash: requestHash,
+			ExpiresAt:   now.Add(IdempotencyTTL()),
+		}
+		if err := initializers.DB.Create(&record).Error; err != nil {
+			var existing models.IdempotencyKey
+			if err := initializers.DB.Where("key = ?", scopedKey).First(&existing).Error; err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+				return
+			}
+
+			switch {
+			case existing.RequestHash != requestHash:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+			case existing.StatusCode == 0:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still in progress"})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.StatusCode, existing.ContentType, existing.ResponseBody)
+				c.Abort()
+			}
+			return
+		}
+
+		// A panicking handler must not leave the key blocked as in progress
+		defer func() {
+			if r := recover(); r != nil {
+				initializers.DB.Delete(&record)
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Server errors are not stored so the client can retry them
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			initializers.DB.Delete(&record)
+			return
+		}
+
+		initializers.DB.Model(&record).Updates(map[string]interface{}{
+			"status_code":   status,
+			"response_body": recorder.body.Bytes(),
+			"content_type":  recorder.Header().Get("Content-Type"),
+		})
+	}
+}
+
+// CleanupIdempotencyKeys deletes expired keys every interval. Run it in a goroutine.
+func CleanupIdempotencyKeys(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		result := initializers.DB.Where("expires_at <= ?", time.Now()).Delete(&models.IdempotencyKey{})
+		if result.Error != nil {
+			log.Printf("idempotency key cleanup failed: %v", result.Error)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"log"
+
+	"github.com/aoncodev/qrbackend/compliance"
 	"github.com/aoncodev/qrbackend/initializers"
 	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/utils"
 )
 
 func init() {
@@ -12,9 +16,95 @@ func init() {
 }
 
 func main() {
-	initializers.DB.AutoMigrate(
+	if err := initializers.DB.AutoMigrate(
 		&models.Employee{},
 		&models.AttendanceLog{},
 		&models.BreakLog{},
+		&models.Kiosk{},
+		&models.IdempotencyKey{},
+		&models.CorrectionRequest{},
+		&models.AuditLog{},
+		&models.WageRate{},
+		&models.WeeklySchedule{},
+		&models.ScheduleOverride{},
+		&models.PlannedShift{},
+		&models.AttendancePolicy{},
+		&models.BreakType{},
+		&models.BreakRule{},
+		&models.PremiumPayRule{},
+		&models.Holiday{},
+	); err != nil {
+		log.Fatalf("auto migrate failed: %v", err)
+	}
+
+	// Clean up rows that would violate the one-open-row indexes before creating them
+	if err := closeDuplicateOpenShifts(initializers.DB); err != nil {
+		log.Fatalf("closing duplicate open shifts failed: %v", err)
+	}
+	if err := closeDuplicateOpenBreaks(initializers.DB); err != nil {
+		log.Fatalf("closing duplicate open breaks failed: %v", err)
+	}
+	mustExec("one open shift index", "CREATE UNIQUE INDEX IF NOT EXISTS idx_attendance_logs_one_open ON attendance_logs (employee_id) WHERE clock_out IS NULL")
+	mustExec("one open break index", "CREATE UNIQUE INDEX IF NOT EXISTS idx_break_logs_one_open ON break_logs (attendance_id) WHERE break_end IS NULL")
+
+	// The audit trail is append-only
+	mustExec("audit update rule", "CREATE OR REPLACE RULE audit_logs_no_update AS ON UPDATE TO audit_logs DO INSTEAD NOTHING")
+	mustExec("audit delete rule", "CREATE OR REPLACE RULE audit_logs_no_delete AS ON DELETE TO audit_logs DO INSTEAD NOTHING")
+
+	// Seed the wage history with each employee's current wage
+	mustExec("wage history seed",
+		`INSERT INTO wage_rates (employee_id, hourly_wage, effective_from, created_at)
+		SELECT e.id, e.hourly_wage, to_char(e.created_at AT TIME ZONE ?, 'YYYY-MM-DD'), now()
+		FROM employees e
+		WHERE NOT EXISTS (SELECT 1 FROM wage_rates w WHERE w.employee_id = e.id)`,
+		utils.BusinessLocation().String(),
+	)
+
+	// A new catalog starts with the default break types
+	var breakTypeCount int64
+	if err := initializers.DB.Model(&models.BreakType{}).Count(&breakTypeCount).Error; err != nil {
+		log.Fatalf("counting break types failed: %v", err)
+	}
+	if breakTypeCount == 0 {
+		for _, breakType := range models.DefaultBreakTypes {
+			if err := initializers.DB.Create(&breakType).Error; err != nil {
+				log.Fatalf("seeding break types failed: %v", err)
+			}
+		}
+	}
+
+	// Fold free-text break types onto lowercase codes and add them to the
+	// catalog as unpaid types, so existing breaks keep being deducted
+	mustExec("break type normalization", "UPDATE break_logs SET break_type = lower(trim(break_type)) WHERE break_type <> lower(trim(break_type))")
+	mustExec("break type seed",
+		`INSERT INTO break_types (code, name, paid, max_minutes, max_per_shift, active, created_at)
+		SELECT DISTINCT break_type, break_type, false, 0, 0, true, now() FROM break_logs
+		ON CONFLICT (code) DO NOTHING`,
+	)
+
+	// Start with the statutory break rules under Korean labor law
+	var breakRuleCount int64
+	if err := initializers.DB.Model(&models.BreakRule{}).Count(&breakRuleCount).Error; err != nil {
+		log.Fatalf("counting break rules failed: %v", err)
+	}
+	if breakRuleCount == 0 {
+		for _, rule := range compliance.DefaultBreakRules {
+			if err := initializers.DB.Create(&models.BreakRule{MinWorkMinutes: rule.MinWorkMinutes, RequiredBreakMinutes: rule.RequiredBreakMinutes}).Error; err != nil {
+				log.Fatalf("seeding break rules failed: %v", err)
+			}
+		}
+	}
+
+	// Attribute shifts recorded before business_date existed to the day they started
+	mustExec("business date backfill",
+		"UPDATE attendance_logs SET business_date = to_char(clock_in AT TIME ZONE ?, 'YYYY-MM-DD') WHERE business_date IS NULL OR business_date = ''",
+		utils.BusinessLocation().String(),
 	)
 }
+
+// mustExec runs a migration statement and stops the migrator if it fails
+func mustExec(step string, sql string, values ...interface{}) {
+	if err := initializers.DB.Exec(sql, values...).Error; err != nil {
+		log.Fatalf("%s failed: %v", step, err)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/aoncodev/qrbackend/models"
+	"gorm.io/gorm"
+)
+
+// closeDuplicateOpenShifts leaves at most one open shift per employee so the
+// one-open-shift index can be created. Every open shift except the latest is
+// closed when the next one started, flagged as auto-closed for admin review
+// and audited. Its open breaks end at the same time.
+func closeDuplicateOpenShifts(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var open []models.AttendanceLog
+		if err := tx.
+			Where("clock_out IS NULL AND employee_id IN (?)",
+				tx.Model(&models.AttendanceLog{}).
+					Select("employee_id").
+					Where("clock_out IS NULL").
+					Group("employee_id").
+					Having("count(*) > 1")).
+			Order("employee_id, clock_in, id").
+			Find(&open).Error; err != nil {
+			return err
+		}
+
+		closed := 0
+		for i := 0; i+1 < len(open); i++ {
+			shift, next := open[i], open[i+1]
+			if shift.EmployeeID != next.EmployeeID {
+				continue // shift is the latest open one of its employee
+			}
+
+			cutoff := next.ClockIn
+			if err := tx.Model(&models.BreakLog{}).
+				Where("attendance_id = ? AND break_end IS NULL", shift.ID).
+				Update("break_end", gorm.Expr("GREATEST(break_start, ?)", cutoff)).Error; err != nil {
+				return err
+			}
+
+			before := shift
+			shift.ClockOut = &cutoff
+			shift.AutoClosed = true
+			if err := tx.Save(&shift).Error; err != nil {
+				return err
+			}
+			if err := auditMigration(tx, "attendance.auto_clock_out", "attendance", shift.ID, before, shift); err != nil {
+				return err
+			}
+			closed++
+		}
+
+		if closed > 0 {
+			log.Printf("closed %d duplicate open shifts", closed)
+		}
+		return nil
+	})
+}
+
+// closeDuplicateOpenBreaks leaves at most one open break per shift so the
+// one-open-break index can be created. Every open break except the latest
+// ends when the next one started.
+func closeDuplicateOpenBreaks(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var open []models.BreakLog
+		if err := tx.
+			Where("break_end IS NULL AND attendance_id IN (?)",
+				tx.Model(&models.BreakLog{}).
+					Select("attendance_id").
+					Where("break_end IS NULL").
+					Group("attendance_id").
+					Having("count(*) > 1")).
+			Order("attendance_id, break_start, id").
+			Find(&open).Error; err != nil {
+			return err
+		}
+
+		closed := 0
+		for i := 0; i+1 < len(open); i++ {
+			b, next := open[i], open[i+1]
+			if b.AttendanceID != next.AttendanceID {
+				continue
+			}
+
+			before := b
+			end := next.BreakStart
+			b.BreakEnd = &end
+			if err := tx.Save(&b).Error; err != nil {
+				return err
+			}
+			if err := auditMigration(tx, "break.auto_end", "break", b.ID, before, b); err != nil {
+				return err
+			}
+			closed++
+		}
+
+		if closed > 0 {
+			log.Printf("closed %d duplicate open breaks", closed)
+		}
+		return nil
+	})
+}
+
+// auditMigration records a change made by the migrator, which has no actor
+func auditMigration(tx *gorm.DB, action, entityType string, entityID uint, before, after interface{}) error {
+	beforeJSON, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.AuditLog{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     models.JSONB(beforeJSON),
+		After:      models.JSONB(afterJSON),
+		CreatedAt:  time.Now(),
+	}).Error
+}
@@ -1,14 +1,31 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/aoncodev/qrbackend/utils"
+	"gorm.io/gorm"
+)
 
 type AttendanceLog struct {
-	ID         uint       `gorm:"primaryKey" json:"id"`
-	EmployeeID uint       `gorm:"not null" json:"employee_id"`
-	ClockIn    time.Time  `gorm:"not null" json:"clock_in"`
-	ClockOut   *time.Time `json:"clock_out"`
-	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	EmployeeID   uint       `gorm:"not null;index" json:"employee_id"` // the migrator adds a unique index allowing one open shift per employee
+	ClockIn      time.Time  `gorm:"not null" json:"clock_in"`
+	ClockOut     *time.Time `json:"clock_out"`
+	BusinessDate string     `gorm:"type:varchar(10);index" json:"business_date"`     // YYYY-MM-DD in the business timezone, taken from clock-in
+	AutoClosed   bool       `gorm:"not null;default:false;index" json:"auto_closed"` // closed by the forgotten clock-out job
+	ReviewedAt   *time.Time `json:"reviewed_at"`
+	ReviewedBy   *uint      `json:"reviewed_by"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
 
 	// ✅ Add this to link with breaks
 	Breaks []BreakLog `gorm:"foreignKey:AttendanceID" json:"breaks"`
 }
+
+// BeforeSave keeps BusinessDate in step with ClockIn, including admin edits
+func (a *AttendanceLog) BeforeSave(tx *gorm.DB) error {
+	if !a.ClockIn.IsZero() {
+		a.BusinessDate = utils.BusinessDate(a.ClockIn)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Rounding modes for AttendancePolicy
+const (
+	RoundNearest = "nearest"
+	RoundUp      = "up"
+	RoundDown    = "down"
+)
+
+// AttendancePolicy holds the organization-wide grace and rounding rules.
+// There is a single row with ID 1.
+type AttendancePolicy struct {
+	ID                     uint      `gorm:"primaryKey" json:"-"`
+	LateGraceMinutes       int       `gorm:"not null;default:0" json:"late_grace_minutes"`
+	EarlyLeaveGraceMinutes int       `gorm:"not null;default:0" json:"early_leave_grace_minutes"`
+	ClockRoundingMinutes   int       `gorm:"not null;default:0" json:"clock_rounding_minutes"` // 0 = no rounding
+	ClockInRounding        string    `gorm:"type:varchar(10);not null;default:'nearest'" json:"clock_in_rounding"`
+	ClockOutRounding       string    `gorm:"type:varchar(10);not null;default:'nearest'" json:"clock_out_rounding"`
+	BreakRoundingMinutes   int       `gorm:"not null;default:0" json:"break_rounding_minutes"` // 0 = no rounding
+	BreakRounding          string    `gorm:"type:varchar(10);not null;default:'nearest'" json:"break_rounding"`
+	UpdatedBy              *uint     `json:"updated_by"`
+	UpdatedAt              time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
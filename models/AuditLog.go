@@ -0,0 +1,52 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// JSONB holds a raw JSON document in a jsonb column
+type JSONB json.RawMessage
+
+func (j JSONB) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+func (j *JSONB) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append((*j)[:0], v...)
+	case string:
+		*j = JSONB(v)
+	default:
+		return errors.New("unsupported type for JSONB")
+	}
+	return nil
+}
+
+func (j JSONB) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+// AuditLog is an append-only record of a change to payroll relevant data.
+// The migrator installs rules that discard UPDATE and DELETE on this table.
+type AuditLog struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ActorID    *uint     `gorm:"index" json:"actor_id"` // nil for system jobs
+	Action     string    `gorm:"type:varchar(50);not null;index" json:"action"`
+	EntityType string    `gorm:"type:varchar(50);not null;index:idx_audit_logs_entity" json:"entity_type"`
+	EntityID   uint      `gorm:"not null;index:idx_audit_logs_entity" json:"entity_id"`
+	Before     JSONB     `gorm:"type:jsonb" json:"before"`
+	After      JSONB     `gorm:"type:jsonb" json:"after"`
+	CreatedAt  time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// BreakRule is a statutory break requirement checked by the compliance
+// report: RequiredBreakMinutes of break per shift with MinWorkMinutes of work
+type BreakRule struct {
+	ID                   uint      `gorm:"primaryKey" json:"id"`
+	MinWorkMinutes       int       `gorm:"not null;uniqueIndex" json:"min_work_minutes"`
+	RequiredBreakMinutes int       `gorm:"not null" json:"required_break_minutes"`
+	CreatedAt            time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
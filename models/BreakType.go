@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// BreakType is an entry in the admin-managed break catalog. BreakLog.BreakType
+// holds its Code.
+type BreakType struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Code        string    `gorm:"type:varchar(50);not null;uniqueIndex" json:"code"` // lowercase, e.g. "lunch"
+	Name        string    `gorm:"type:varchar(100);not null" json:"name"`
+	Paid        bool      `gorm:"not null;default:false" json:"paid"`
+	MaxMinutes  int       `gorm:"not null;default:0" json:"max_minutes"`   // 0 = no limit
+	MaxPerShift int       `gorm:"not null;default:0" json:"max_per_shift"` // 0 = no limit
+	Active      bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// DefaultBreakTypes seed an empty catalog: an unpaid meal break and a short
+// paid rest break
+var DefaultBreakTypes = []BreakType{
+	{Code: "meal", Name: "Meal", Paid: false, MaxPerShift: 1, Active: true},
+	{Code: "rest", Name: "Rest", Paid: true, MaxMinutes: 15, Active: true},
+	{Code: "personal", Name: "Personal", Paid: false, Active: true},
+}
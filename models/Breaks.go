@@ -4,10 +4,11 @@ package models
 import "time"
 
 type BreakLog struct {
-	ID           uint       `gorm:"primaryKey" json:"id"`
-	AttendanceID uint       `gorm:"not null" json:"attendance_id"`
-	BreakType    string     `gorm:"type:varchar(50);not null" json:"break_type"`
-	BreakStart   time.Time  `gorm:"not null" json:"break_start"`
-	BreakEnd     *time.Time `json:"break_end"`
-	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	AttendanceID      uint       `gorm:"not null;index" json:"attendance_id"` // the migrator adds a unique index allowing one open break per shift
+	BreakType         string     `gorm:"type:varchar(50);not null" json:"break_type"`
+	BreakStart        time.Time  `gorm:"not null" json:"break_start"`
+	BreakEnd          *time.Time `json:"break_end"`
+	OverrunNotifiedAt *time.Time `json:"overrun_notified_at"` // set once the overrun alert has been sent
+	CreatedAt         time.Time  `gorm:"autoCreateTime" json:"created_at"`
 }
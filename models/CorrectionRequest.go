@@ -0,0 +1,60 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ProposedBreak is one break in a correction request
+type ProposedBreak struct {
+	BreakType string     `json:"break_type"`
+	Start     time.Time  `json:"start"`
+	End       *time.Time `json:"end"`
+}
+
+// ProposedBreaks is stored as jsonb. A nil list leaves the breaks untouched;
+// an empty list removes them.
+type ProposedBreaks []ProposedBreak
+
+func (p ProposedBreaks) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return json.Marshal(p)
+}
+
+func (p *ProposedBreaks) Scan(value interface{}) error {
+	if value == nil {
+		*p = nil
+		return nil
+	}
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for ProposedBreaks")
+	}
+	return json.Unmarshal(data, p)
+}
+
+// CorrectionRequest is an employee's request to fix an attendance record,
+// or to add a missing one when AttendanceID is nil
+type CorrectionRequest struct {
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	EmployeeID       uint           `gorm:"not null;index" json:"employee_id"`
+	AttendanceID     *uint          `gorm:"index" json:"attendance_id"`
+	ProposedClockIn  *time.Time     `json:"proposed_clock_in"`
+	ProposedClockOut *time.Time     `json:"proposed_clock_out"`
+	ProposedBreaks   ProposedBreaks `gorm:"type:jsonb" json:"proposed_breaks"`
+	Reason           string         `gorm:"type:text;not null" json:"reason"`
+	Status           string         `gorm:"type:varchar(20);not null;default:pending;index" json:"status"` // "pending", "approved" or "rejected"
+	ReviewedBy       *uint          `json:"reviewed_by"`
+	ReviewedAt       *time.Time     `json:"reviewed_at"`
+	ReviewNote       string         `gorm:"type:text" json:"review_note"`
+	CreatedAt        time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -7,10 +7,17 @@ type Employee struct {
 	ID         uint      `gorm:"primaryKey" json:"id"`
 	Name       string    `gorm:"type:varchar(100);not null" json:"name"`
 	QRID       string    `gorm:"type:varchar(50);unique;not null" json:"qr_id"`
-	HourlyWage int       `gorm:"type:int;not null" json:"hourly_wage"`
+	HourlyWage int       `gorm:"type:int;not null" json:"hourly_wage"` // starting wage; the current rate comes from the wage history
 	Role       string    `gorm:"type:varchar(20);not null" json:"role"`       // "admin" or "employee"
 	StartTime  string    `gorm:"type:varchar(5);not null" json:"start_time"`  // stores time as "HH:MM"
 	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
 	OTP        string    `gorm:"column:otp" json:"otp"`
+
+	// Deactivated employees keep their history but can no longer clock in
+	Active          bool    `gorm:"not null;default:true;index" json:"active"`
+	TerminationDate *string `gorm:"type:varchar(10)" json:"termination_date"` // last business day worked, YYYY-MM-DD
+
+	// Signed QR tokens issued before this instant are rejected (rotation/revocation)
+	QRValidFrom *time.Time `json:"-"`
 }
 
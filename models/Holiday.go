@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Holiday is a public holiday or company closure. Nobody is expected to work
+// on it unless a schedule override or roster shift says otherwise, and work
+// on it is paid the holiday premium.
+type Holiday struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Date      string    `gorm:"type:varchar(10);not null;uniqueIndex" json:"date"` // YYYY-MM-DD
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// IdempotencyKey stores the first response to a request sent with an
+// Idempotency-Key header so retries can be answered with the same result
+type IdempotencyKey struct {
+	Key          string    `gorm:"primaryKey;type:varchar(255)" json:"key"` // sha256 of the caller, route and header value
+	RequestHash  string    `gorm:"type:varchar(64);not null" json:"request_hash"`
+	StatusCode   int       `gorm:"not null;default:0" json:"status_code"` // 0 while the first request is still running
+	ResponseBody []byte    `json:"-"`
+	ContentType  string    `gorm:"type:varchar(100)" json:"content_type"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ExpiresAt    time.Time `gorm:"not null;index" json:"expires_at"`
+}
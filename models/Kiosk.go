@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+type Kiosk struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Name       string    `gorm:"type:varchar(100);not null" json:"name"`
+	Location   string    `gorm:"type:varchar(100)" json:"location"`
+	Secret     string    `gorm:"type:varchar(64);not null" json:"-"`        // hex encoded code secret, never leaves the server
+	APIKeyHash string    `gorm:"type:varchar(64);unique;not null" json:"-"` // sha256 of the device key
+	Active     bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// PlannedShift is one rostered shift. An end time at or before the start
+// means the shift runs past midnight.
+type PlannedShift struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	EmployeeID uint      `gorm:"not null;index:idx_planned_shifts_employee_date" json:"employee_id"`
+	Date       string    `gorm:"type:varchar(10);not null;index:idx_planned_shifts_employee_date;index" json:"date"` // business date, YYYY-MM-DD
+	StartTime  string    `gorm:"type:varchar(5);not null" json:"start_time"`                                         // "HH:MM"
+	EndTime    string    `gorm:"type:varchar(5);not null" json:"end_time"`                                           // "HH:MM"
+	Position   string    `gorm:"type:varchar(50)" json:"position"`
+	Note       string    `gorm:"type:text" json:"note"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Weekdays is a list of weekdays (0 = Sunday ... 6 = Saturday) stored as jsonb
+type Weekdays []int
+
+func (w Weekdays) Value() (driver.Value, error) {
+	if w == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(w)
+	return string(data), err
+}
+
+func (w *Weekdays) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*w = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for Weekdays")
+	}
+	return json.Unmarshal(data, w)
+}
+
+// PremiumPayRule holds the overtime, night and holiday premium settings.
+// There is a single row with ID 1. Premiums are added to the base rate, so
+// 0.5 pays 150%.
+type PremiumPayRule struct {
+	ID                     uint      `gorm:"primaryKey" json:"-"`
+	DailyOvertimeMinutes   int       `gorm:"not null" json:"daily_overtime_minutes"`  // 0 = no daily overtime
+	WeeklyOvertimeMinutes  int       `gorm:"not null" json:"weekly_overtime_minutes"` // 0 = no weekly overtime
+	OvertimePremium        float64   `gorm:"not null" json:"overtime_premium"`
+	NightStart             string    `gorm:"type:varchar(5);not null" json:"night_start"` // "HH:MM"
+	NightEnd               string    `gorm:"type:varchar(5);not null" json:"night_end"`   // "HH:MM"
+	NightPremium           float64   `gorm:"not null" json:"night_premium"`
+	HolidayPremium         float64   `gorm:"not null" json:"holiday_premium"`
+	HolidayOvertimePremium float64   `gorm:"not null" json:"holiday_overtime_premium"`
+	RestWeekdays           Weekdays  `gorm:"type:jsonb;not null;default:'[]'" json:"rest_weekdays"` // work on these weekdays is paid as holiday work
+	UpdatedBy              *uint     `json:"updated_by"`
+	UpdatedAt              time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// WeeklySchedule is an employee's regular hours on one weekday. Weekdays
+// without a row are days off.
+type WeeklySchedule struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	EmployeeID uint      `gorm:"not null;uniqueIndex:idx_weekly_schedules_employee_day" json:"employee_id"`
+	Weekday    int       `gorm:"not null;uniqueIndex:idx_weekly_schedules_employee_day" json:"weekday"` // 0 = Sunday ... 6 = Saturday
+	StartTime  string    `gorm:"type:varchar(5);not null" json:"start_time"`                            // "HH:MM"
+	EndTime    string    `gorm:"type:varchar(5);not null" json:"end_time"`                              // "HH:MM", earlier than start means next day
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ScheduleOverride replaces the weekly schedule on one business date
+type ScheduleOverride struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	EmployeeID uint      `gorm:"not null;uniqueIndex:idx_schedule_overrides_employee_date" json:"employee_id"`
+	Date       string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_schedule_overrides_employee_date" json:"date"` // YYYY-MM-DD
+	DayOff     bool      `gorm:"not null;default:false" json:"day_off"`
+	StartTime  string    `gorm:"type:varchar(5)" json:"start_time"`
+	EndTime    string    `gorm:"type:varchar(5)" json:"end_time"`
+	Note       string    `gorm:"type:text" json:"note"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
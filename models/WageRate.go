@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// WageRate is an hourly wage that applies to shifts on or after EffectiveFrom
+type WageRate struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	EmployeeID    uint      `gorm:"not null;uniqueIndex:idx_wage_rates_employee_date" json:"employee_id"`
+	HourlyWage    int       `gorm:"type:int;not null" json:"hourly_wage"`
+	EffectiveFrom string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_wage_rates_employee_date" json:"effective_from"` // business date, YYYY-MM-DD
+	CreatedBy     *uint     `json:"created_by"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
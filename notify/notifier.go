@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Event is something managers should hear about as it happens
+type Event struct {
+	Type       string                 `json:"type"` // e.g. "break.overrun"
+	EmployeeID uint                   `json:"employee_id"`
+	Message    string                 `json:"message"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+// Notifier delivers events to whoever is watching
+type Notifier interface {
+	Notify(event Event) error
+}
+
+// LogNotifier writes events to the server log
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(event Event) error {
+	log.Printf("[%s] employee %d: %s", event.Type, event.EmployeeID, event.Message)
+	return nil
+}
+
+// WebhookNotifier POSTs each event as JSON, e.g. to a Slack or Teams relay
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (n WebhookNotifier) Notify(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	resp, err := n.Client.Post(n.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Multi sends every event to each notifier, returning the first error
+type Multi []Notifier
+
+func (m Multi) Notify(event Event) error {
+	var first error
+	for _, n := range m {
+		if err := n.Notify(event); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// NewFromEnv logs every event and also posts it to NOTIFY_WEBHOOK_URL when set
+func NewFromEnv() Notifier {
+	notifiers := Multi{LogNotifier{}}
+	if url := os.Getenv("NOTIFY_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, WebhookNotifier{URL: url, Client: &http.Client{Timeout: 10 * time.Second}})
+	}
+	return notifiers
+}
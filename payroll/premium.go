@@ -0,0 +1,280 @@
+package payroll
+
+import (
+	"strings"
+	"time"
+)
+
+// Interval is a stretch of paid work
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// WorkShift is one shift's paid work, attributed to the business date it
+// started on
+type WorkShift struct {
+	ID           uint
+	BusinessDate string // YYYY-MM-DD
+	Intervals    []Interval
+}
+
+// PremiumRules configures premium pay. Premiums are added to the base rate,
+// so 0.5 pays 150%; night premium stacks on top of the others.
+type PremiumRules struct {
+	DailyOvertimeMinutes   int     `json:"daily_overtime_minutes"`  // 0 = no daily overtime
+	WeeklyOvertimeMinutes  int     `json:"weekly_overtime_minutes"` // 0 = no weekly overtime
+	OvertimePremium        float64 `json:"overtime_premium"`
+	NightStart             string  `json:"night_start"` // "HH:MM"
+	NightEnd               string  `json:"night_end"`   // "HH:MM", before NightStart wraps past midnight
+	NightPremium           float64 `json:"night_premium"`
+	HolidayPremium         float64 `json:"holiday_premium"`
+	HolidayOvertimePremium float64 `json:"holiday_overtime_premium"` // replaces overtime + holiday premium past the daily limit on a holiday
+}
+
+// DefaultPremiumRules follow the Korean Labor Standards Act: +50% past 8
+// hours a day or 40 a week, +50% for 22:00-06:00, +50% on holidays and +100%
+// for holiday hours past 8
+var DefaultPremiumRules = PremiumRules{
+	DailyOvertimeMinutes:   8 * 60,
+	WeeklyOvertimeMinutes:  40 * 60,
+	OvertimePremium:        0.5,
+	NightStart:             "22:00",
+	NightEnd:               "06:00",
+	NightPremium:           0.5,
+	HolidayPremium:         0.5,
+	HolidayOvertimePremium: 1.0,
+}
+
+// Segment is a stretch of work paid at one rate
+type Segment struct {
+	ShiftID    uint      `json:"attendance_id"`
+	Date       string    `json:"date"` // business date of the shift
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Minutes    float64   `json:"minutes"`
+	Kind       string    `json:"kind"` // "regular", or flags joined by "+", e.g. "overtime+night"
+	Overtime   bool      `json:"overtime"`
+	Night      bool      `json:"night"`
+	Holiday    bool      `json:"holiday"`
+	Multiplier float64   `json:"multiplier"`
+	HourlyWage int       `json:"hourly_wage"`
+	Wage       float64   `json:"wage"`
+}
+
+// Calculator splits shifts into segments and prices them
+type Calculator struct {
+	Rules     PremiumRules
+	Location  *time.Location
+	IsHoliday func(date string) bool // calendar date in Location
+	RateOn    func(date string) int  // hourly wage on a business date
+}
+
+// clockMinutes parses "HH:MM" into minutes after midnight
+func clockMinutes(clock string) (int, bool) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+func (c Calculator) isNight(t time.Time) bool {
+	start, okStart := clockMinutes(c.Rules.NightStart)
+	end, okEnd := clockMinutes(c.Rules.NightEnd)
+	if !okStart || !okEnd || start == end {
+		return false
+	}
+	local := t.In(c.Location)
+	m := local.Hour()*60 + local.Minute()
+	if start < end {
+		return m >= start && m < end
+	}
+	return m >= start || m < end
+}
+
+// nextBoundary is the first midnight or night window edge after t
+func (c Calculator) nextBoundary(t time.Time) time.Time {
+	local := t.In(c.Location)
+	var next time.Time
+	consider := func(candidate time.Time) {
+		if candidate.After(t) && (next.IsZero() || candidate.Before(next)) {
+			next = candidate
+		}
+	}
+	for offset := 0; offset <= 1; offset++ {
+		day := local.AddDate(0, 0, offset)
+		consider(time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, c.Location))
+		for _, clock := range []string{c.Rules.NightStart, c.Rules.NightEnd} {
+			if m, ok := clockMinutes(clock); ok {
+				consider(time.Date(day.Year(), day.Month(), day.Day(), m/60, m%60, 0, 0, c.Location))
+			}
+		}
+	}
+	return next
+}
+
+func (c Calculator) multiplier(overtime, night, holiday bool) float64 {
+	m := 1.0
+	switch {
+	case holiday && overtime:
+		m += c.Rules.HolidayOvertimePremium
+	case holiday:
+		m += c.Rules.HolidayPremium
+	case overtime:
+		m += c.Rules.OvertimePremium
+	}
+	if night {
+		m += c.Rules.NightPremium
+	}
+	return m
+}
+
+func segmentKind(overtime, night, holiday bool) string {
+	var flags []string
+	if overtime {
+		flags = append(flags, "overtime")
+	}
+	if night {
+		flags = append(flags, "night")
+	}
+	if holiday {
+		flags = append(flags, "holiday")
+	}
+	if len(flags) == 0 {
+		return "regular"
+	}
+	return strings.Join(flags, "+")
+}
+
+// limitReached reports whether worked minutes have reached a limit; 0 disables it
+func limitReached(limit int, worked time.Duration) bool {
+	return limit > 0 && worked >= time.Duration(limit)*time.Minute
+}
+
+// untilLimit is how much more work fits under a limit, or -1 when disabled
+func untilLimit(limit int, worked time.Duration) time.Duration {
+	if limit <= 0 {
+		return -1
+	}
+	return time.Duration(limit)*time.Minute - worked
+}
+
+// Price splits shifts into segments. Shifts must be in clock-in order and,
+// for weekly overtime to be right, cover every shift of each ISO week they
+// touch. Daily overtime counts per business date; weekly overtime counts
+// the minutes that were not already daily overtime.
+func (c Calculator) Price(shifts []WorkShift) []Segment {
+	segments := []Segment{}
+	dayWorked := map[string]time.Duration{}
+	weekRegular := map[string]time.Duration{}
+
+	for _, shift := range shifts {
+		day, err := time.ParseInLocation("2006-01-02", shift.BusinessDate, c.Location)
+		if err != nil {
+			continue
+		}
+		week := ISOWeek(day)
+		rate := c.RateOn(shift.BusinessDate)
+
+		for _, interval := range shift.Intervals {
+			cursor := interval.Start
+			for cursor.Before(interval.End) {
+				next := c.nextBoundary(cursor)
+				if next.After(interval.End) {
+					next = interval.End
+				}
+
+				overtime := limitReached(c.Rules.DailyOvertimeMinutes, dayWorked[shift.BusinessDate]) ||
+					limitReached(c.Rules.WeeklyOvertimeMinutes, weekRegular[week])
+				if !overtime {
+					// Cut where the first limit is reached
+					for _, remaining := range []time.Duration{
+						untilLimit(c.Rules.DailyOvertimeMinutes, dayWorked[shift.BusinessDate]),
+						untilLimit(c.Rules.WeeklyOvertimeMinutes, weekRegular[week]),
+					} {
+						if remaining > 0 && cursor.Add(remaining).Before(next) {
+							next = cursor.Add(remaining)
+						}
+					}
+				}
+
+				night := c.isNight(cursor)
+				holiday := c.IsHoliday != nil && c.IsHoliday(cursor.In(c.Location).Format("2006-01-02"))
+				length := next.Sub(cursor)
+				dayWorked[shift.BusinessDate] += length
+				if !overtime {
+					weekRegular[week] += length
+				}
+
+				segments = appendSegment(segments, Segment{
+					ShiftID:    shift.ID,
+					Date:       shift.BusinessDate,
+					Start:      cursor,
+					End:        next,
+					Overtime:   overtime,
+					Night:      night,
+					Holiday:    holiday,
+					Multiplier: c.multiplier(overtime, night, holiday),
+					HourlyWage: rate,
+				})
+				cursor = next
+			}
+		}
+	}
+
+	for i := range segments {
+		s := &segments[i]
+		s.Minutes = s.End.Sub(s.Start).Minutes()
+		s.Kind = segmentKind(s.Overtime, s.Night, s.Holiday)
+		s.Wage = s.Minutes / 60.0 * float64(s.HourlyWage) * s.Multiplier
+	}
+	return segments
+}
+
+// appendSegment merges s into the previous segment when they are contiguous
+// and paid the same way
+func appendSegment(segments []Segment, s Segment) []Segment {
+	if n := len(segments); n > 0 {
+		last := &segments[n-1]
+		if last.ShiftID == s.ShiftID && last.End.Equal(s.Start) &&
+			last.Overtime == s.Overtime && last.Night == s.Night && last.Holiday == s.Holiday &&
+			last.HourlyWage == s.HourlyWage {
+			last.End = s.End
+			return segments
+		}
+	}
+	return append(segments, s)
+}
+
+// Totals adds up priced segments. Premium is the pay above the base rate.
+type Totals struct {
+	Minutes         float64 `json:"minutes"`
+	OvertimeMinutes float64 `json:"overtime_minutes"`
+	NightMinutes    float64 `json:"night_minutes"`
+	HolidayMinutes  float64 `json:"holiday_minutes"`
+	BaseWage        float64 `json:"base_wage"`
+	PremiumWage     float64 `json:"premium_wage"`
+	Wage            float64 `json:"wage"`
+}
+
+func SumSegments(segments []Segment) Totals {
+	var t Totals
+	for _, s := range segments {
+		t.Minutes += s.Minutes
+		if s.Overtime {
+			t.OvertimeMinutes += s.Minutes
+		}
+		if s.Night {
+			t.NightMinutes += s.Minutes
+		}
+		if s.Holiday {
+			t.HolidayMinutes += s.Minutes
+		}
+		base := s.Minutes / 60.0 * float64(s.HourlyWage)
+		t.BaseWage += base
+		t.PremiumWage += s.Wage - base
+		t.Wage += s.Wage
+	}
+	return t
+}
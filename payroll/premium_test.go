@@ -0,0 +1,446 @@
+package payroll
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load location %s: %v", name, err)
+	}
+	return loc
+}
+
+// at builds a wall-clock time; hours past 23 roll into the next day
+func at(loc *time.Location, date string, hour, minute int) time.Time {
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		panic(err)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+}
+
+func shift(id uint, date string, intervals ...Interval) WorkShift {
+	return WorkShift{ID: id, BusinessDate: date, Intervals: intervals}
+}
+
+// wantSegment is the part of a Segment a test cares about
+type wantSegment struct {
+	start, end string // "01-02 15:04" in the calculator's location
+	kind       string
+	multiplier float64
+	rate       int
+}
+
+func flatRate(rate int) func(string) int {
+	return func(string) int { return rate }
+}
+
+func TestPrice(t *testing.T) {
+	seoul := mustLocation(t, "Asia/Seoul")
+	newYork := mustLocation(t, "America/New_York")
+
+	// Monday to Friday 9:00-17:00 in the week of 2026-01-05
+	fullWeek := func(loc *time.Location) []WorkShift {
+		var shifts []WorkShift
+		for i, date := range []string{"2026-01-05", "2026-01-06", "2026-01-07", "2026-01-08", "2026-01-09"} {
+			shifts = append(shifts, shift(uint(i+1), date, Interval{at(loc, date, 9, 0), at(loc, date, 17, 0)}))
+		}
+		return shifts
+	}
+
+	tests := []struct {
+		name      string
+		loc       *time.Location
+		rules     PremiumRules
+		holidays  map[string]bool
+		rateOn    func(string) int
+		shifts    []WorkShift
+		want      []wantSegment
+		wantTotal float64
+	}{
+		{
+			name:   "regular day below every threshold",
+			loc:    seoul,
+			rules:  DefaultPremiumRules,
+			rateOn: flatRate(10000),
+			shifts: []WorkShift{shift(1, "2026-01-05", Interval{at(seoul, "2026-01-05", 9, 0), at(seoul, "2026-01-05", 17, 0)})},
+			want: []wantSegment{
+				{"01-05 09:00", "01-05 17:00", "regular", 1, 10000},
+			},
+			wantTotal: 80000,
+		},
+		{
+			name:   "daily threshold crossed mid-segment",
+			loc:    seoul,
+			rules:  DefaultPremiumRules,
+			rateOn: flatRate(10000),
+			shifts: []WorkShift{shift(1, "2026-01-05", Interval{at(seoul, "2026-01-05", 8, 30), at(seoul, "2026-01-05", 18, 0)})},
+			want: []wantSegment{
+				{"01-05 08:30", "01-05 16:30", "regular", 1, 10000},
+				{"01-05 16:30", "01-05 18:00", "overtime", 1.5, 10000},
+			},
+			wantTotal: 80000 + 22500,
+		},
+		{
+			name:   "daily threshold counts across split intervals and shifts of one day",
+			loc:    seoul,
+			rules:  DefaultPremiumRules,
+			rateOn: flatRate(10000),
+			shifts: []WorkShift{
+				shift(1, "2026-01-05", Interval{at(seoul, "2026-01-05", 6, 0), at(seoul, "2026-01-05", 10, 0)}),
+				shift(2, "2026-01-05",
+					Interval{at(seoul, "2026-01-05", 12, 0), at(seoul, "2026-01-05", 15, 0)},
+					Interval{at(seoul, "2026-01-05", 16, 0), at(seoul, "2026-01-05", 18, 0)}),
+			},
+			want: []wantSegment{
+				{"01-05 06:00", "01-05 10:00", "regular", 1, 10000},
+				{"01-05 12:00", "01-05 15:00", "regular", 1, 10000},
+				{"01-05 16:00", "01-05 17:00", "regular", 1, 10000},
+				{"01-05 17:00", "01-05 18:00", "overtime", 1.5, 10000},
+			},
+			wantTotal: 80000 + 15000,
+		},
+		{
+			name:   "weekly threshold crossed mid-segment on the sixth day",
+			loc:    seoul,
+			rules:  DefaultPremiumRules,
+			rateOn: flatRate(10000),
+			shifts: append(fullWeek(seoul),
+				shift(6, "2026-01-10", Interval{at(seoul, "2026-01-10", 10, 0), at(seoul, "2026-01-10", 14, 0)})),
+			want: []wantSegment{
+				{"01-05 09:00", "01-05 17:00", "regular", 1, 10000},
+				{"01-06 09:00", "01-06 17:00", "regular", 1, 10000},
+				{"01-07 09:00", "01-07 17:00", "regular", 1, 10000},
+				{"01-08 09:00", "01-08 17:00", "regular", 1, 10000},
+				{"01-09 09:00", "01-09 17:00", "regular", 1, 10000},
+				{"01-10 10:00", "01-10 14:00", "overtime", 1.5, 10000},
+			},
+			wantTotal: 400000 + 60000,
+		},
+		{
+			name:   "weekly threshold reached part way through a shift",
+			loc:    seoul,
+			rules:  DefaultPremiumRules,
+			rateOn: flatRate(10000),
+			shifts: []WorkShift{
+				shift(1, "2026-01-05", Interval{at(seoul, "2026-01-05", 9, 0), at(seoul, "2026-01-05", 17, 0)}),
+				shift(2, "2026-01-06", Interval{at(seoul, "2026-01-06", 9, 0), at(seoul, "2026-01-06", 17, 0)}),
+				shift(3, "2026-01-07", Interval{at(seoul, "2026-01-07", 9, 0), at(seoul, "2026-01-07", 17, 0)}),
+				shift(4, "2026-01-08", Interval{at(seoul, "2026-01-08", 9, 0), at(seoul, "2026-01-08", 17, 0)}),
+				shift(5, "2026-01-09", Interval{at(seoul, "2026-01-09", 9, 0), at(seoul, "2026-01-09", 15, 0)}),
+				shift(6, "2026-01-10", Interval{at(seoul, "2026-01-10", 9, 0), at(seoul, "2026-01-10", 13, 0)}),
+			},
+			want: []wantSegment{
+				{"01-05 09:00", "01-05 17:00", "regular", 1, 10000},
+				{"01-06 09:00", "01-06 17:00", "regular", 1, 10000},
+				{"01-07 09:00", "01-07 17:00", "regular", 1, 10000},
+				{"01-08 09:00", "01-08 17:00", "regular", 1, 10000},
+				{"01-09 09:00", "01-09 15:00", "regular", 1, 10000},
+				{"01-10 09:00", "01-10 11:00", "regular", 1, 10000},
+				{"01-10 11:00", "01-10 13:00", "overtime", 1.5, 10000},
+			},
+			wantTotal: 400000 + 30000,
+		},
+		{
+			name:   "daily overtime does not count toward the weekly threshold",
+			loc:    seoul,
+			rules:  DefaultPremiumRules,
+			rateOn: flatRate(10000),
+			shifts: []WorkShift{
+				shift(1, "2026-01-05", Interval{at(seoul, "2026-01-05", 8, 0), at(seoul, "2026-01-05", 20, 0)}),
+				shift(2, "2026-01-06", Interval{at(seoul, "2026-01-06", 9, 0), at(seoul, "2026-01-06", 17, 0)}),
+				shift(3, "2026-01-07", Interval{at(seoul, "2026-01-07", 9, 0), at(seoul, "2026-01-07", 17, 0)}),
+				shift(4, "2026-01-08", Interval{at(seoul, "2026-01-08", 9, 0), at(seoul, "2026-01-08", 17, 0)}),
+				shift(5, "2026-01-09", Interval{at(seoul, "2026-01-09", 9, 0), at(seoul, "2026-01-09", 17, 0)}),
+			},
+			want: []wantSegment{
+				{"01-05 08:00", "01-05 16:00", "regular", 1, 10000},
+				{"01-05 16:00", "01-05 20:00", "overtime", 1.5, 10000},
+				{"01-06 09:00", "01-06 17:00", "regular", 1, 10000},
+				{"01-07 09:00", "01-07 17:00", "regular", 1, 10000},
+				{"01-08 09:00", "01-08 17:00", "regular", 1, 10000},
+				{"01-09 09:00", "01-09 17:00", "regular", 1, 10000},
+			},
+			wantTotal: 400000 + 60000,
+		},
+		{
+			name:   "weeks are counted separately",
+			loc:    seoul,
+			rules:  DefaultPremiumRules,
+			rateOn: flatRate(10000),
+			shifts: append(fullWeek(seoul),
+				shift(6, "2026-01-12", Interval{at(seoul, "2026-01-12", 9, 0), at(seoul, "2026-01-12", 13, 0)})),
+			want: []wantSegment{
+				{"01-05 09:00", "01-05 17:00", "regular", 1, 10000},
+				{"01-06 09:00", "01-06 17:00", "regular", 1, 10000},
+				{"01-07 09:00", "01-07 17:00", "regular", 1, 10000},
+				{"01-08 09:00", "01-08 17:00", "regular", 1, 10000},
+				{"01-09 09:00", "01-09 17:00", "regular", 1, 10000},
+				{"01-12 09:00", "01-12 13:00", "regular", 1, 10000},
+			},
+			wantTotal: 440000,
+		},
+		{
+			name:   "night window crossing midnight",
+			loc:    seoul,
+			rules:  DefaultPremiumRules,
+			rateOn: flatRate(10000),
+			shifts: []WorkShift{shift(1, "2026-01-05", Interval{at(seoul, "2026-01-05", 20, 0), at(seoul, "2026-01-06", 3, 0)})},
+			want: []wantSegment{
+				{"01-05 20:00", "01-05 22:00", "regular", 1, 10000},
+				{"01-05 22:00", "01-06 03:00", "night", 1.5, 10000},
+			},
+			wantTotal: 20000 + 75000,
+		},
+		{
+			name:   "night window ends at 06:00",
+			loc:    seoul,
+			rules:  DefaultPremiumRules,
+			rateOn: flatRate(10000),
+			shifts: []WorkShift{shift(1, "2026-01-05", Interval{at(seoul, "2026-01-05", 4, 0), at(seoul, "2026-01-05", 8, 0)})},
+			want: []wantSegment{
+				{"01-05 04:00", "01-05 06:00", "night", 1.5, 10000},
+				{"01-05 06:00", "01-05 08:00", "regular", 1, 10000},
+			},
+			wantTotal: 30000 + 20000,
+		},
+		{
+			name: "night window that does not wrap",
+			loc:  seoul,
+			rules: PremiumRules{
+				NightStart:   "00:00",
+				NightEnd:     "05:00",
+				NightPremium: 0.25,
+			},
+			rateOn: flatRate(10000),
+			shifts: []WorkShift{shift(1, "2026-01-05", Interval{at(seoul, "2026-01-05", 23, 0), at(seoul, "2026-01-06", 6, 0)})},
+			want: []wantSegment{
+				{"01-05 23:00", "01-06 00:00", "regular", 1, 10000},
+				{"01-06 00:00", "01-06 05:00", "night", 1.25, 10000},
+				{"01-06 05:00", "01-06 06:00", "regular", 1, 10000},
+			},
+			wantTotal: 10000 + 62500 + 10000,
+		},
+		{
+			name:   "overtime and night stack",
+			loc:    seoul,
+			rules:  DefaultPremiumRules,
+			rateOn: flatRate(10000),
+			shifts: []WorkShift{shift(1, "2026-01-05", Interval{at(seoul, "2026-01-05", 14, 0), at(seoul, "2026-01-05", 24, 0)})},
+			want: []wantSegment{
+				{"01-05 14:00", "01-05 22:00", "regular", 1, 10000},
+				{"01-05 22:00", "01-06 00:00", "overtime+night", 2, 10000},
+			},
+			wantTotal: 80000 + 40000,
+		},
+		{
+			name:     "holiday within the daily limit",
+			loc:      seoul,
+			rules:    DefaultPremiumRules,
+			holidays: map[string]bool{"2026-01-05": true},
+			rateOn:   flatRate(10000),
+			shifts:   []WorkShift{shift(1, "2026-01-05", Interval{at(seoul, "2026-01-05", 9, 0), at(seoul, "2026-01-05", 17, 0)})},
+			want: []wantSegment{
+				{"01-05 09:00", "01-05 17:00", "holiday", 1.5, 10000},
+			},
+			wantTotal: 120000,
+		},
+		{
+			name:     "holiday plus night plus overtime stacking",
+			loc:      seoul,
+			rules:    DefaultPremiumRules,
+			holidays: map[string]bool{"2026-01-05": true},
+			rateOn:   flatRate(10000),
+			shifts:   []WorkShift{shift(1, "2026-01-05", Interval{at(seoul, "2026-01-05", 13, 0), at(seoul, "2026-01-05", 26, 0)})},
+			want: []wantSegment{
+				{"01-05 13:00", "01-05 21:00", "holiday", 1.5, 10000},
+				{"01-05 21:00", "01-05 22:00", "overtime+holiday", 2, 10000},
+				{"01-05 22:00", "01-06 00:00", "overtime+night+holiday", 2.5, 10000},
+				{"01-06 00:00", "01-06 02:00", "overtime+night", 2, 10000},
+			},
+			wantTotal: 120000 + 20000 + 50000 + 40000,
+		},
+		{
+			name:     "holiday starts at midnight during a shift",
+			loc:      seoul,
+			rules:    DefaultPremiumRules,
+			holidays: map[string]bool{"2026-01-06": true},
+			rateOn:   flatRate(10000),
+			shifts:   []WorkShift{shift(1, "2026-01-05", Interval{at(seoul, "2026-01-05", 21, 0), at(seoul, "2026-01-06", 7, 0)})},
+			want: []wantSegment{
+				{"01-05 21:00", "01-05 22:00", "regular", 1, 10000},
+				{"01-05 22:00", "01-06 00:00", "night", 1.5, 10000},
+				{"01-06 00:00", "01-06 05:00", "night+holiday", 2, 10000},
+				{"01-06 05:00", "01-06 06:00", "overtime+night+holiday", 2.5, 10000},
+				{"01-06 06:00", "01-06 07:00", "overtime+holiday", 2, 10000},
+			},
+			wantTotal: 10000 + 30000 + 100000 + 25000 + 20000,
+		},
+		{
+			name:  "wage change mid-period prices each shift at its own date's rate",
+			loc:   seoul,
+			rules: DefaultPremiumRules,
+			rateOn: func(date string) int {
+				if date >= "2026-01-07" {
+					return 12000
+				}
+				return 10000
+			},
+			shifts: []WorkShift{
+				shift(1, "2026-01-06", Interval{at(seoul, "2026-01-06", 9, 0), at(seoul, "2026-01-06", 13, 0)}),
+				shift(2, "2026-01-07", Interval{at(seoul, "2026-01-07", 9, 0), at(seoul, "2026-01-07", 13, 0)}),
+			},
+			want: []wantSegment{
+				{"01-06 09:00", "01-06 13:00", "regular", 1, 10000},
+				{"01-07 09:00", "01-07 13:00", "regular", 1, 12000},
+			},
+			wantTotal: 40000 + 48000,
+		},
+		{
+			name:  "overnight shift keeps the rate of the day it started",
+			loc:   seoul,
+			rules: PremiumRules{},
+			rateOn: func(date string) int {
+				if date >= "2026-01-07" {
+					return 12000
+				}
+				return 10000
+			},
+			shifts: []WorkShift{shift(1, "2026-01-06", Interval{at(seoul, "2026-01-06", 20, 0), at(seoul, "2026-01-07", 4, 0)})},
+			want: []wantSegment{
+				{"01-06 20:00", "01-07 04:00", "regular", 1, 10000},
+			},
+			wantTotal: 80000,
+		},
+		{
+			name:   "disabled thresholds and night window pay everything at base",
+			loc:    seoul,
+			rules:  PremiumRules{},
+			rateOn: flatRate(10000),
+			shifts: []WorkShift{shift(1, "2026-01-05", Interval{at(seoul, "2026-01-05", 12, 0), at(seoul, "2026-01-06", 2, 0)})},
+			want: []wantSegment{
+				{"01-05 12:00", "01-06 02:00", "regular", 1, 10000},
+			},
+			wantTotal: 140000,
+		},
+		{
+			name:   "spring forward: the lost hour is not paid",
+			loc:    newYork,
+			rules:  DefaultPremiumRules,
+			rateOn: flatRate(20),
+			// 2024-03-10 02:00 EST jumps to 03:00 EDT; 23:00-07:00 is 7 real hours
+			shifts: []WorkShift{shift(1, "2024-03-09", Interval{at(newYork, "2024-03-09", 23, 0), at(newYork, "2024-03-10", 7, 0)})},
+			want: []wantSegment{
+				{"03-09 23:00", "03-10 06:00", "night", 1.5, 20},
+				{"03-10 06:00", "03-10 07:00", "regular", 1, 20},
+			},
+			wantTotal: 6*20*1.5 + 20,
+		},
+		{
+			name:   "fall back: the repeated hour is paid",
+			loc:    newYork,
+			rules:  DefaultPremiumRules,
+			rateOn: flatRate(20),
+			// 2024-11-03 02:00 EDT falls back to 01:00 EST; 23:00-07:00 is 9 real hours
+			shifts: []WorkShift{shift(1, "2024-11-02", Interval{at(newYork, "2024-11-02", 23, 0), at(newYork, "2024-11-03", 7, 0)})},
+			want: []wantSegment{
+				{"11-02 23:00", "11-03 06:00", "night", 1.5, 20},
+				{"11-03 06:00", "11-03 07:00", "overtime", 1.5, 20},
+			},
+			wantTotal: 8*20*1.5 + 20*1.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := Calculator{
+				Rules:     tt.rules,
+				Location:  tt.loc,
+				IsHoliday: func(date string) bool { return tt.holidays[date] },
+				RateOn:    tt.rateOn,
+			}
+			got := calc.Price(tt.shifts)
+
+			if len(got) != len(tt.want) {
+				for _, s := range got {
+					t.Logf("got %s-%s %s x%.2f", s.Start.In(tt.loc).Format("01-02 15:04"), s.End.In(tt.loc).Format("01-02 15:04"), s.Kind, s.Multiplier)
+				}
+				t.Fatalf("got %d segments, want %d", len(got), len(tt.want))
+			}
+			for i, w := range tt.want {
+				s := got[i]
+				start := s.Start.In(tt.loc).Format("01-02 15:04")
+				end := s.End.In(tt.loc).Format("01-02 15:04")
+				if start != w.start || end != w.end || s.Kind != w.kind || s.Multiplier != w.multiplier || s.HourlyWage != w.rate {
+					t.Errorf("segment %d = %s-%s %s x%.2f @%d, want %s-%s %s x%.2f @%d",
+						i, start, end, s.Kind, s.Multiplier, s.HourlyWage, w.start, w.end, w.kind, w.multiplier, w.rate)
+				}
+				if s.Minutes != s.End.Sub(s.Start).Minutes() {
+					t.Errorf("segment %d minutes = %v, want the real elapsed %v", i, s.Minutes, s.End.Sub(s.Start).Minutes())
+				}
+			}
+
+			if total := SumSegments(got).Wage; total != tt.wantTotal {
+				t.Errorf("total wage = %v, want %v", total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestSumSegments(t *testing.T) {
+	loc := mustLocation(t, "Asia/Seoul")
+	calc := Calculator{
+		Rules:     DefaultPremiumRules,
+		Location:  loc,
+		IsHoliday: func(date string) bool { return date == "2026-01-05" },
+		RateOn:    flatRate(10000),
+	}
+	segments := calc.Price([]WorkShift{shift(1, "2026-01-05", Interval{at(loc, "2026-01-05", 14, 0), at(loc, "2026-01-05", 24, 0)})})
+	totals := SumSegments(segments)
+
+	want := Totals{
+		Minutes:         600,
+		OvertimeMinutes: 120,
+		NightMinutes:    120,
+		HolidayMinutes:  600,
+		BaseWage:        100000,
+		// 8h holiday at +50%, 2h holiday overtime at +100% plus night +50%
+		PremiumWage: 40000 + 30000,
+		Wage:        170000,
+	}
+	if totals != want {
+		t.Errorf("SumSegments = %+v, want %+v", totals, want)
+	}
+}
+
+func TestAppendSegmentMerges(t *testing.T) {
+	loc := mustLocation(t, "Asia/Seoul")
+	a := Segment{ShiftID: 1, Start: at(loc, "2026-01-05", 9, 0), End: at(loc, "2026-01-05", 10, 0), HourlyWage: 10000}
+	tests := []struct {
+		name string
+		next Segment
+		want int
+	}{
+		{"contiguous and same pay merges", Segment{ShiftID: 1, Start: a.End, End: at(loc, "2026-01-05", 11, 0), HourlyWage: 10000}, 1},
+		{"gap does not merge", Segment{ShiftID: 1, Start: at(loc, "2026-01-05", 10, 30), End: at(loc, "2026-01-05", 11, 0), HourlyWage: 10000}, 2},
+		{"different shift does not merge", Segment{ShiftID: 2, Start: a.End, End: at(loc, "2026-01-05", 11, 0), HourlyWage: 10000}, 2},
+		{"different flags do not merge", Segment{ShiftID: 1, Start: a.End, End: at(loc, "2026-01-05", 11, 0), Night: true, HourlyWage: 10000}, 2},
+		{"different rate does not merge", Segment{ShiftID: 1, Start: a.End, End: at(loc, "2026-01-05", 11, 0), HourlyWage: 12000}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := appendSegment([]Segment{a}, tt.next)
+			if len(got) != tt.want {
+				t.Fatalf("got %d segments, want %d", len(got), tt.want)
+			}
+			if tt.want == 1 && !got[0].End.Equal(tt.next.End) {
+				t.Errorf("merged end = %v, want %v", got[0].End, tt.next.End)
+			}
+		})
+	}
+}
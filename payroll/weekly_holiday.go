@@ -0,0 +1,121 @@
+// Package payroll computes pay items that depend on more than one day of
+// attendance. Controllers gather the schedule and attendance for a week and
+// pass it in as plain values.
+package payroll
+
+import (
+	"fmt"
+	"time"
+)
+
+// Weekly holiday allowance (주휴수당) thresholds under the Korean Labor
+// Standards Act: employees with 15+ contracted hours a week who attend every
+// contracted day get a paid day off, prorated against a 40-hour week.
+const (
+	MinWeeklyMinutes      = 15 * 60
+	FullTimeWeeklyMinutes = 40 * 60
+	FullAllowanceMinutes  = 8 * 60
+)
+
+// Reasons a week earns no allowance
+const (
+	ReasonWeekNotFinished = "week_not_finished"
+	ReasonUnder15Hours    = "under_15_hours"
+	ReasonMissedDay       = "missed_scheduled_day"
+	ReasonEmploymentEnded = "employment_ended"
+)
+
+// Day is one calendar day of a week as payroll sees it
+type Day struct {
+	Date             string // YYYY-MM-DD
+	Scheduled        bool   // a shift with known hours was scheduled
+	Holiday          bool   // the scheduled shift falls on a holiday off
+	ScheduledMinutes int
+	Attended         bool // any shift started on this day
+	WorkedMinutes    int  // paid minutes
+}
+
+// WeeklyHolidayAllowance is the allowance breakdown for one ISO week
+type WeeklyHolidayAllowance struct {
+	Week            string  `json:"week"`       // ISO week, e.g. "2026-W07"
+	WeekStart       string  `json:"week_start"` // Monday
+	WeekEnd         string  `json:"week_end"`   // Sunday
+	ContractMinutes int     `json:"contract_minutes"`
+	WorkedMinutes   int     `json:"worked_minutes"`
+	ScheduledDays   int     `json:"scheduled_days"`
+	AttendedDays    int     `json:"attended_days"`
+	Eligible        bool    `json:"eligible"`
+	Reason          string  `json:"reason,omitempty"`
+	AllowanceHours  float64 `json:"allowance_hours"`
+	HourlyWage      int     `json:"hourly_wage"`
+	Amount          float64 `json:"amount"`
+}
+
+// ISOWeek labels the ISO week a date falls in, e.g. "2026-W07"
+func ISOWeek(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// WeekStart returns the Monday of t's ISO week, at t's time of day
+func WeekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7 // Monday = 0
+	return t.AddDate(0, 0, -offset)
+}
+
+// WeeklyHolidayAllowanceFor computes the allowance for one Monday-to-Sunday
+// week. Contracted minutes are the scheduled minutes; days scheduled without
+// known hours count their worked minutes instead and do not need attendance.
+// A holiday off keeps its scheduled minutes in the contract without
+// needing attendance.
+// finished reports whether the week is over and employed whether the
+// employment continues past it.
+func WeeklyHolidayAllowanceFor(week []Day, hourlyWage int, finished, employed bool) WeeklyHolidayAllowance {
+	result := WeeklyHolidayAllowance{HourlyWage: hourlyWage}
+	if len(week) > 0 {
+		start, _ := time.Parse("2006-01-02", week[0].Date)
+		result.Week = ISOWeek(start)
+		result.WeekStart = week[0].Date
+		result.WeekEnd = week[len(week)-1].Date
+	}
+
+	for _, day := range week {
+		result.WorkedMinutes += day.WorkedMinutes
+		if day.Scheduled && day.Holiday {
+			result.ContractMinutes += day.ScheduledMinutes
+		} else if day.Scheduled {
+			result.ScheduledDays++
+			result.ContractMinutes += day.ScheduledMinutes
+			if day.Attended {
+				result.AttendedDays++
+			}
+		} else {
+			result.ContractMinutes += day.WorkedMinutes
+		}
+	}
+
+	switch {
+	case !finished:
+		result.Reason = ReasonWeekNotFinished
+	case !employed:
+		result.Reason = ReasonEmploymentEnded
+	case result.ContractMinutes < MinWeeklyMinutes:
+		result.Reason = ReasonUnder15Hours
+	case result.AttendedDays < result.ScheduledDays:
+		result.Reason = ReasonMissedDay
+	default:
+		result.Eligible = true
+	}
+	if !result.Eligible {
+		return result
+	}
+
+	basis := result.ContractMinutes
+	if basis > FullTimeWeeklyMinutes {
+		basis = FullTimeWeeklyMinutes
+	}
+	allowanceMinutes := float64(basis) / FullTimeWeeklyMinutes * FullAllowanceMinutes
+	result.AllowanceHours = allowanceMinutes / 60.0
+	result.Amount = result.AllowanceHours * float64(hourlyWage)
+	return result
+}
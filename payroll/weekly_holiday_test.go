@@ -0,0 +1,120 @@
+package payroll
+
+import "testing"
+
+// partTimeWeek is Monday to Thursday 4h shifts starting 2026-01-05
+func partTimeWeek() []Day {
+	dates := []string{"2026-01-05", "2026-01-06", "2026-01-07", "2026-01-08", "2026-01-09", "2026-01-10", "2026-01-11"}
+	week := make([]Day, len(dates))
+	for i, date := range dates {
+		week[i].Date = date
+		if i < 4 {
+			week[i].Scheduled = true
+			week[i].ScheduledMinutes = 240
+			week[i].Attended = true
+			week[i].WorkedMinutes = 240
+		}
+	}
+	return week
+}
+
+func TestWeeklyHolidayAllowanceFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		week       func() []Day
+		finished   bool
+		employed   bool
+		wantReason string
+		wantHours  float64
+	}{
+		{
+			name:      "16 contracted hours earn a prorated allowance",
+			week:      partTimeWeek,
+			finished:  true,
+			employed:  true,
+			wantHours: 16.0 / 40 * 8,
+		},
+		{
+			name: "a holiday off keeps its contracted hours",
+			week: func() []Day {
+				week := partTimeWeek()
+				week[2].Holiday = true
+				week[2].Attended = false
+				week[2].WorkedMinutes = 0
+				return week
+			},
+			finished:  true,
+			employed:  true,
+			wantHours: 16.0 / 40 * 8,
+		},
+		{
+			name: "a missed scheduled day loses the allowance",
+			week: func() []Day {
+				week := partTimeWeek()
+				week[2].Attended = false
+				week[2].WorkedMinutes = 0
+				return week
+			},
+			finished:   true,
+			employed:   true,
+			wantReason: ReasonMissedDay,
+		},
+		{
+			name: "under 15 contracted hours",
+			week: func() []Day {
+				week := partTimeWeek()
+				week[3] = Day{Date: week[3].Date}
+				return week
+			},
+			finished:   true,
+			employed:   true,
+			wantReason: ReasonUnder15Hours,
+		},
+		{
+			name:       "week not finished",
+			week:       partTimeWeek,
+			employed:   true,
+			wantReason: ReasonWeekNotFinished,
+		},
+		{
+			name:       "employment ended",
+			week:       partTimeWeek,
+			finished:   true,
+			wantReason: ReasonEmploymentEnded,
+		},
+		{
+			name: "contract above 40 hours is capped",
+			week: func() []Day {
+				week := partTimeWeek()
+				for i := 0; i < 5; i++ {
+					week[i] = Day{Date: week[i].Date, Scheduled: true, ScheduledMinutes: 600, Attended: true, WorkedMinutes: 600}
+				}
+				return week
+			},
+			finished:  true,
+			employed:  true,
+			wantHours: 8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := WeeklyHolidayAllowanceFor(tt.week(), 10000, tt.finished, tt.employed)
+			if got.Reason != tt.wantReason {
+				t.Errorf("reason = %q, want %q", got.Reason, tt.wantReason)
+			}
+			if got.Eligible != (tt.wantReason == "") {
+				t.Errorf("eligible = %v with reason %q", got.Eligible, got.Reason)
+			}
+			if got.AllowanceHours != tt.wantHours {
+				t.Errorf("allowance hours = %v, want %v", got.AllowanceHours, tt.wantHours)
+			}
+			if got.Amount != tt.wantHours*10000 {
+				t.Errorf("amount = %v, want %v", got.Amount, tt.wantHours*10000)
+			}
+			if got.Week != "2026-W02" {
+				t.Errorf("week = %q, want 2026-W02", got.Week)
+			}
+		})
+	}
+}
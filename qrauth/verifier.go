@@ -0,0 +1,134 @@
+package qrauth
+
+import (
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"github.com/aoncodev/qrbackend/models"
+	"github.com/aoncodev/qrbackend/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEmployeeNotFound = errors.New("employee not found")
+	ErrInvalidCode      = errors.New("invalid QR code")
+	ErrExpiredCode      = errors.New("QR code expired")
+	ErrRevokedCode      = errors.New("QR code revoked")
+)
+
+// Verifier resolves the content of a scanned QR code to an employee
+type Verifier interface {
+	Verify(code string) (*models.Employee, error)
+}
+
+// SignedTokenVerifier accepts HMAC-signed tokens issued by utils.GenerateQRToken
+type SignedTokenVerifier struct {
+	DB  *gorm.DB
+	TTL time.Duration
+}
+
+func (v SignedTokenVerifier) Verify(code string) (*models.Employee, error) {
+	claims, err := utils.ParseQRToken(code)
+	if err != nil {
+		return nil, ErrInvalidCode
+	}
+
+	now := time.Now()
+	if claims.IssuedAt.After(now.Add(time.Minute)) {
+		return nil, ErrInvalidCode
+	}
+	if v.TTL > 0 && now.Sub(claims.IssuedAt) > v.TTL {
+		return nil, ErrExpiredCode
+	}
+
+	var employee models.Employee
+	if err := v.DB.First(&employee, claims.EmployeeID).Error; err != nil {
+		return nil, ErrEmployeeNotFound
+	}
+
+	// Tokens issued before the last rotation/revocation are no longer valid
+	if employee.QRValidFrom != nil && claims.IssuedAt.Before(employee.QRValidFrom.Truncate(time.Second)) {
+		return nil, ErrRevokedCode
+	}
+
+	return &employee, nil
+}
+
+// LegacyQRIDVerifier matches the static Employee.QRID printed on old badges.
+// It is only used during a migration window and rejects every code once
+// Until has passed.
+type LegacyQRIDVerifier struct {
+	DB    *gorm.DB
+	Until time.Time
+}
+
+func (v LegacyQRIDVerifier) Verify(code string) (*models.Employee, error) {
+	if !time.Now().Before(v.Until) {
+		return nil, ErrExpiredCode
+	}
+
+	var employee models.Employee
+	if err := v.DB.Where("qr_id = ?", code).First(&employee).Error; err != nil {
+		return nil, ErrEmployeeNotFound
+	}
+	return &employee, nil
+}
+
+// ChainVerifier sends signed tokens to the signed verifier and everything
+// else to the legacy verifier, if one is configured.
+type ChainVerifier struct {
+	Signed Verifier
+	Legacy Verifier
+}
+
+func (v ChainVerifier) Verify(code string) (*models.Employee, error) {
+	if utils.IsQRToken(code) {
+		return v.Signed.Verify(code)
+	}
+	if v.Legacy == nil {
+		return nil, ErrInvalidCode
+	}
+	return v.Legacy.Verify(code)
+}
+
+// TokenTTL is the validity window of a signed token, read from QR_TOKEN_TTL
+// (Go duration, e.g. "720h"). Printed badges are meant to last, so the
+// default is zero: tokens only expire when the employee's QR is rotated or
+// revoked.
+func TokenTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("QR_TOKEN_TTL"))
+	if err != nil || ttl < 0 {
+		return 0
+	}
+	return ttl
+}
+
+// LegacyUntil is the end of the window in which the plain QR IDs of old
+// badges are still accepted, read from QR_ALLOW_LEGACY_UNTIL (YYYY-MM-DD,
+// exclusive, in the business timezone). Unset or invalid disables them.
+func LegacyUntil() (time.Time, bool) {
+	until := os.Getenv("QR_ALLOW_LEGACY_UNTIL")
+	if until == "" {
+		return time.Time{}, false
+	}
+	day, err := utils.ParseBusinessDate(until)
+	if err != nil {
+		log.Printf("invalid QR_ALLOW_LEGACY_UNTIL %q, legacy QR IDs are rejected: %v", until, err)
+		return time.Time{}, false
+	}
+	return day, true
+}
+
+// NewFromEnv builds the verifier chain. Only signed tokens are accepted
+// unless QR_ALLOW_LEGACY_UNTIL opens a window for the old QR IDs.
+func NewFromEnv(db *gorm.DB) Verifier {
+	chain := ChainVerifier{
+		Signed: SignedTokenVerifier{DB: db, TTL: TokenTTL()},
+	}
+	if until, ok := LegacyUntil(); ok {
+		chain.Legacy = LegacyQRIDVerifier{DB: db, Until: until}
+	}
+	return chain
+}
@@ -0,0 +1,120 @@
+// Package shiftstate models an employee's attendance status and the clock
+// actions allowed from each status. Controllers derive the current State
+// from the database and ask Transition whether an action is legal, so every
+// endpoint reports and enforces the same rules.
+package shiftstate
+
+// State is an employee's live attendance status
+type State string
+
+const (
+	NotClockedIn State = "not_clocked_in" // no shift on the current business day
+	Working      State = "working"        // shift open, no open break
+	OnBreak      State = "on_break"       // shift open with an open break
+	ClockedOut   State = "clocked_out"    // every shift of the day is closed
+)
+
+// Day-level statuses used in daily attendance lists. A finished day reads
+// "present", a scheduled day without shifts "absent", a holiday without
+// shifts "holiday" and any other unscheduled day without shifts
+// "not_scheduled".
+const (
+	Present      State = "present"
+	Absent       State = "absent"
+	Holiday      State = "holiday"
+	NotScheduled State = "not_scheduled"
+)
+
+// Action is a clock or break event
+type Action string
+
+const (
+	ClockIn    Action = "clock_in"
+	ClockOut   Action = "clock_out"
+	StartBreak Action = "break_start"
+	EndBreak   Action = "break_end"
+)
+
+// Snapshot is what the database says about an employee right now
+type Snapshot struct {
+	HasShiftToday bool // any shift on the current business day
+	ShiftOpen     bool // a shift without clock-out
+	BreakOpen     bool // the open shift has a break without end
+}
+
+// Of derives the live state from a snapshot
+func Of(s Snapshot) State {
+	switch {
+	case s.ShiftOpen && s.BreakOpen:
+		return OnBreak
+	case s.ShiftOpen:
+		return Working
+	case s.HasShiftToday:
+		return ClockedOut
+	default:
+		return NotClockedIn
+	}
+}
+
+// DayStatus maps a live state to the status shown in daily attendance
+func DayStatus(s State) State {
+	switch s {
+	case NotClockedIn:
+		return Absent
+	case ClockedOut:
+		return Present
+	default:
+		return s
+	}
+}
+
+// TransitionError is returned for an action that is not allowed from a state
+type TransitionError struct {
+	From    State
+	Action  Action
+	Message string
+}
+
+func (e *TransitionError) Error() string { return e.Message }
+
+type transition struct {
+	from   State
+	action Action
+}
+
+var transitions = map[transition]State{
+	{NotClockedIn, ClockIn}: Working,
+	{ClockedOut, ClockIn}:   Working, // further shifts are limited by the shifts-per-day policy, not here
+	{Working, StartBreak}:   OnBreak,
+	{OnBreak, EndBreak}:     Working,
+	{Working, ClockOut}:     ClockedOut,
+}
+
+// rejections explain the illegal transitions in the words the kiosk shows
+var rejections = map[transition]string{
+	{Working, ClockIn}:         "You are already clocked in",
+	{OnBreak, ClockIn}:         "You are already clocked in",
+	{NotClockedIn, ClockOut}:   "No active attendance log found",
+	{ClockedOut, ClockOut}:     "No active attendance log found",
+	{OnBreak, ClockOut}:        "You must end your break before clocking out",
+	{NotClockedIn, StartBreak}: "You are not clocked in",
+	{ClockedOut, StartBreak}:   "You are not clocked in",
+	{OnBreak, StartBreak}:      "You must end your current break before starting a new one",
+	{NotClockedIn, EndBreak}:   "No active break found",
+	{ClockedOut, EndBreak}:     "No active break found",
+	{Working, EndBreak}:        "No active break found",
+}
+
+// Transition returns the state after applying action, or a *TransitionError
+func Transition(from State, action Action) (State, error) {
+	key := transition{from, action}
+	if to, ok := transitions[key]; ok {
+		return to, nil
+	}
+
+	message, ok := rejections[key]
+	if !ok {
+		message = "Action " + string(action) + " is not allowed while " + string(from)
+	}
+	return from, &TransitionError{From: from, Action: action, Message: message}
+}
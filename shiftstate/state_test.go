@@ -0,0 +1,121 @@
+package shiftstate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOf(t *testing.T) {
+	tests := []struct {
+		snapshot Snapshot
+		want     State
+	}{
+		{Snapshot{}, NotClockedIn},
+		{Snapshot{HasShiftToday: true}, ClockedOut},
+		{Snapshot{HasShiftToday: true, ShiftOpen: true}, Working},
+		{Snapshot{HasShiftToday: true, ShiftOpen: true, BreakOpen: true}, OnBreak},
+		// a shift opened on a previous business day is still open
+		{Snapshot{ShiftOpen: true}, Working},
+		{Snapshot{ShiftOpen: true, BreakOpen: true}, OnBreak},
+		// a break cannot be open without an open shift
+		{Snapshot{HasShiftToday: true, BreakOpen: true}, ClockedOut},
+		{Snapshot{BreakOpen: true}, NotClockedIn},
+	}
+
+	for _, tt := range tests {
+		if got := Of(tt.snapshot); got != tt.want {
+			t.Errorf("Of(%+v) = %s, want %s", tt.snapshot, got, tt.want)
+		}
+	}
+}
+
+func TestDayStatus(t *testing.T) {
+	tests := []struct {
+		state State
+		want  State
+	}{
+		{NotClockedIn, Absent},
+		{ClockedOut, Present},
+		{Working, Working},
+		{OnBreak, OnBreak},
+	}
+
+	for _, tt := range tests {
+		if got := DayStatus(tt.state); got != tt.want {
+			t.Errorf("DayStatus(%s) = %s, want %s", tt.state, got, tt.want)
+		}
+	}
+}
+
+func TestTransition(t *testing.T) {
+	tests := []struct {
+		from    State
+		action  Action
+		want    State
+		message string // empty when the transition is allowed
+	}{
+		{NotClockedIn, ClockIn, Working, ""},
+		{NotClockedIn, ClockOut, NotClockedIn, "No active attendance log found"},
+		{NotClockedIn, StartBreak, NotClockedIn, "You are not clocked in"},
+		{NotClockedIn, EndBreak, NotClockedIn, "No active break found"},
+
+		{Working, ClockIn, Working, "You are already clocked in"},
+		{Working, ClockOut, ClockedOut, ""},
+		{Working, StartBreak, OnBreak, ""},
+		{Working, EndBreak, Working, "No active break found"},
+
+		{OnBreak, ClockIn, OnBreak, "You are already clocked in"},
+		{OnBreak, ClockOut, OnBreak, "You must end your break before clocking out"},
+		{OnBreak, StartBreak, OnBreak, "You must end your current break before starting a new one"},
+		{OnBreak, EndBreak, Working, ""},
+
+		{ClockedOut, ClockIn, Working, ""},
+		{ClockedOut, ClockOut, ClockedOut, "No active attendance log found"},
+		{ClockedOut, StartBreak, ClockedOut, "You are not clocked in"},
+		{ClockedOut, EndBreak, ClockedOut, "No active break found"},
+
+		// day-level statuses are not live states and allow nothing
+		{Absent, ClockIn, Absent, "Action clock_in is not allowed while absent"},
+		{Present, ClockOut, Present, "Action clock_out is not allowed while present"},
+	}
+
+	for _, tt := range tests {
+		got, err := Transition(tt.from, tt.action)
+		if got != tt.want {
+			t.Errorf("Transition(%s, %s) = %s, want %s", tt.from, tt.action, got, tt.want)
+		}
+
+		if tt.message == "" {
+			if err != nil {
+				t.Errorf("Transition(%s, %s) error = %v, want nil", tt.from, tt.action, err)
+			}
+			continue
+		}
+
+		var terr *TransitionError
+		if !errors.As(err, &terr) {
+			t.Errorf("Transition(%s, %s) error = %v, want *TransitionError", tt.from, tt.action, err)
+			continue
+		}
+		if terr.Message != tt.message || terr.From != tt.from || terr.Action != tt.action {
+			t.Errorf("Transition(%s, %s) error = %+v, want message %q", tt.from, tt.action, terr, tt.message)
+		}
+	}
+}
+
+// every live state and action pair must be either allowed or explained
+func TestTransitionTablesCoverLiveStates(t *testing.T) {
+	states := []State{NotClockedIn, Working, OnBreak, ClockedOut}
+	actions := []Action{ClockIn, ClockOut, StartBreak, EndBreak}
+
+	for _, s := range states {
+		for _, a := range actions {
+			key := transition{s, a}
+			_, allowed := transitions[key]
+			_, rejected := rejections[key]
+			if allowed == rejected {
+				t.Errorf("(%s, %s): allowed=%v rejected=%v, want exactly one", s, a, allowed, rejected)
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// BadgeLayout describes an A4 label grid; all lengths are in millimetres
+type BadgeLayout struct {
+	Columns     int     `json:"columns"`
+	Rows        int     `json:"rows"`
+	LabelWidth  float64 `json:"label_width"`
+	LabelHeight float64 `json:"label_height"`
+	MarginTop   float64 `json:"margin_top"`
+	MarginLeft  float64 `json:"margin_left"`
+	GapX        float64 `json:"gap_x"`
+	GapY        float64 `json:"gap_y"`
+}
+
+// BadgeLayouts are the built-in templates, named after common A4 label sheets
+var BadgeLayouts = map[string]BadgeLayout{
+	// 21 labels, 63.5 x 38.1 mm (L7160)
+	"a4-3x7": {Columns: 3, Rows: 7, LabelWidth: 63.5, LabelHeight: 38.1, MarginTop: 15.15, MarginLeft: 7.25, GapX: 2.5, GapY: 0},
+	// 14 labels, 99.1 x 38.1 mm (L7163)
+	"a4-2x7": {Columns: 2, Rows: 7, LabelWidth: 99.1, LabelHeight: 38.1, MarginTop: 15.15, MarginLeft: 4.65, GapX: 2.5, GapY: 0},
+	// 8 labels, 99.1 x 67.7 mm (L7165)
+	"a4-2x4": {Columns: 2, Rows: 4, LabelWidth: 99.1, LabelHeight: 67.7, MarginTop: 13.1, MarginLeft: 4.65, GapX: 2.5, GapY: 0},
+}
+
+const DefaultBadgeLayout = "a4-3x7"
+
+// Validate checks that the grid fits on an A4 page
+func (l BadgeLayout) Validate() error {
+	if l.Columns < 1 || l.Rows < 1 || l.LabelWidth <= 0 || l.LabelHeight <= 0 {
+		return fmt.Errorf("layout needs at least one row and column and a positive label size")
+	}
+	width := l.MarginLeft + float64(l.Columns)*l.LabelWidth + float64(l.Columns-1)*l.GapX
+	height := l.MarginTop + float64(l.Rows)*l.LabelHeight + float64(l.Rows-1)*l.GapY
+	if width > 210 || height > 297 {
+		return fmt.Errorf("layout does not fit on an A4 page")
+	}
+	return nil
+}
+
+// Badge is one label on the sheet
+type Badge struct {
+	Name      string
+	Role      string
+	QRContent string
+}
+
+// RenderBadgeSheet lays the badges out on as many A4 pages as needed.
+// Set BADGE_FONT_PATH to a UTF-8 TTF font (e.g. NanumGothic) to print
+// non-Latin names; the built-in Helvetica only covers Latin-1.
+func RenderBadgeSheet(badges []Badge, layout BadgeLayout) ([]byte, error) {
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+
+	fontFamily := "Helvetica"
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+	if fontPath := os.Getenv("BADGE_FONT_PATH"); fontPath != "" {
+		pdf.AddUTF8Font("badge", "", fontPath)
+		fontFamily = "badge"
+		translate = func(s string) string { return s }
+	}
+
+	perPage := layout.Columns * layout.Rows
+	padding := 2.0
+
+	for i, badge := range badges {
+		if i%perPage == 0 {
+			pdf.AddPage()
+		}
+		slot := i % perPage
+		x := layout.MarginLeft + float64(slot%layout.Columns)*(layout.LabelWidth+layout.GapX)
+		y := layout.MarginTop + float64(slot/layout.Columns)*(layout.LabelHeight+layout.GapY)
+
+		// QR on the left as a square filling the label height, text to the right
+		qrSize := layout.LabelHeight - 2*padding
+		if qrSize > layout.LabelWidth/2 {
+			qrSize = layout.LabelWidth / 2
+		}
+
+		png, err := RenderQRPNG(badge.QRContent, QRImageOptions{Size: 512, Level: "M", Margin: 1})
+		if err != nil {
+			return nil, fmt.Errorf("badge %q: %w", badge.Name, err)
+		}
+		imageName := fmt.Sprintf("qr-%d", i)
+		pdf.RegisterImageOptionsReader(imageName, fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(png))
+		pdf.ImageOptions(imageName, x+padding, y+padding, qrSize, qrSize, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
+		textX := x + 2*padding + qrSize
+		textWidth := layout.LabelWidth - qrSize - 3*padding
+
+		pdf.SetFont(fontFamily, "", 12)
+		pdf.SetXY(textX, y+layout.LabelHeight/2-6)
+		pdf.CellFormat(textWidth, 6, translate(badge.Name), "", 2, "L", false, 0, "")
+
+		pdf.SetFont(fontFamily, "", 9)
+		pdf.SetX(textX)
+		pdf.CellFormat(textWidth, 5, translate(badge.Role), "", 0, "L", false, 0, "")
+
+		if err := pdf.Error(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(badges) == 0 {
+		pdf.AddPage()
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	businessLocation     *time.Location
+	businessLocationOnce sync.Once
+)
+
+// BusinessLocation is the timezone business days are counted in, from
+// BUSINESS_TIMEZONE (IANA name), defaulting to Asia/Seoul
+func BusinessLocation() *time.Location {
+	businessLocationOnce.Do(func() {
+		name := os.Getenv("BUSINESS_TIMEZONE")
+		if name == "" {
+			name = "Asia/Seoul"
+		}
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			log.Printf("invalid BUSINESS_TIMEZONE %q, falling back to UTC: %v", name, err)
+			loc = time.UTC
+		}
+		businessLocation = loc
+	})
+	return businessLocation
+}
+
+// BusinessDate returns the YYYY-MM-DD business day t falls on. A shift is
+// attributed to the business date of its clock-in, so overnight shifts
+// stay on the day they started.
+func BusinessDate(t time.Time) string {
+	return t.In(BusinessLocation()).Format(businessDateLayout)
+}
+
+// MaxShiftsPerDay is how many attendance logs an employee may open per
+// business day, from MAX_SHIFTS_PER_DAY. 0 means unlimited; default is 1.
+func MaxShiftsPerDay() int {
+	max, err := strconv.Atoi(os.Getenv("MAX_SHIFTS_PER_DAY"))
+	if err != nil || max < 0 {
+		return 1
+	}
+	return max
+}
+
+const businessDateLayout = "2006-01-02"
+
+// ParseBusinessDate parses a YYYY-MM-DD date as midnight in the business timezone
+func ParseBusinessDate(date string) (time.Time, error) {
+	return time.ParseInLocation(businessDateLayout, date, BusinessLocation())
+}
+
+// BusinessTimeOn returns the instant a wall-clock "HH:MM" occurs on a business
+// day. A time inside a DST gap is read with the offset before the gap, so
+// 02:30 on a spring-forward night is 03:30 in the new offset; a time that
+// occurs twice when clocks fall back is its first occurrence.
+func BusinessTimeOn(date string, clock string) (time.Time, error) {
+	day, err := ParseBusinessDate(date)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return wallClockIn(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), day.Location()), nil
+}
+
+// wallClockIn resolves a wall-clock time in loc. time.Date leaves the choice
+// of offset in DST gaps and overlaps unspecified, so both candidate offsets
+// are tried explicitly.
+func wallClockIn(year int, month time.Month, day, hour, minute int, loc *time.Location) time.Time {
+	wall := time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	_, before := wall.Add(-12 * time.Hour).In(loc).Zone()
+	_, after := wall.Add(12 * time.Hour).In(loc).Zone()
+
+	first := wall.Add(-time.Duration(before) * time.Second).In(loc)
+	if first.Hour() == hour && first.Minute() == minute {
+		return first
+	}
+	second := wall.Add(-time.Duration(after) * time.Second).In(loc)
+	if second.Hour() == hour && second.Minute() == minute {
+		return second
+	}
+	// In a gap neither offset reproduces the wall clock
+	return first
+}
+
+// InBusinessTime converts t to the business timezone for display
+func InBusinessTime(t time.Time) time.Time {
+	return t.In(BusinessLocation())
+}
+
+// InBusinessTimeOrNil is InBusinessTime for optional times such as an open
+// shift's clock-out
+func InBusinessTimeOrNil(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	local := InBusinessTime(*t)
+	return &local
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// useBusinessLocation pins the business timezone for one test
+func useBusinessLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load location %s: %v", name, err)
+	}
+	businessLocationOnce.Do(func() {})
+	previous := businessLocation
+	businessLocation = loc
+	t.Cleanup(func() { businessLocation = previous })
+	return loc
+}
+
+func TestBusinessDate(t *testing.T) {
+	useBusinessLocation(t, "Asia/Seoul")
+
+	tests := []struct {
+		at   string // RFC 3339
+		want string
+	}{
+		{"2026-01-05T14:59:59Z", "2026-01-05"}, // 23:59:59 KST
+		{"2026-01-05T15:00:00Z", "2026-01-06"}, // midnight KST
+		{"2026-01-05T00:00:00+09:00", "2026-01-05"},
+		{"2026-01-04T23:59:59+09:00", "2026-01-04"},
+		{"2025-12-31T15:00:00Z", "2026-01-01"},
+	}
+
+	for _, tt := range tests {
+		at, err := time.Parse(time.RFC3339, tt.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := BusinessDate(at); got != tt.want {
+			t.Errorf("BusinessDate(%s) = %s, want %s", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestBusinessTimeOn(t *testing.T) {
+	tests := []struct {
+		zone  string
+		date  string
+		clock string
+		want  string // RFC 3339
+	}{
+		{"Asia/Seoul", "2026-01-05", "00:00", "2026-01-05T00:00:00+09:00"},
+		{"Asia/Seoul", "2026-01-05", "23:59", "2026-01-05T23:59:00+09:00"},
+		{"Asia/Seoul", "2026-01-05", "09:30", "2026-01-05T09:30:00+09:00"},
+
+		// spring forward: 02:00-03:00 does not exist
+		{"Europe/Berlin", "2024-03-31", "01:59", "2024-03-31T01:59:00+01:00"},
+		{"Europe/Berlin", "2024-03-31", "02:30", "2024-03-31T03:30:00+02:00"},
+		{"Europe/Berlin", "2024-03-31", "03:00", "2024-03-31T03:00:00+02:00"},
+		{"America/New_York", "2024-03-10", "02:30", "2024-03-10T03:30:00-04:00"},
+		{"America/New_York", "2024-03-10", "00:00", "2024-03-10T00:00:00-05:00"},
+
+		// fall back: 01:00-02:00 happens twice, the first one is used
+		{"America/New_York", "2024-11-03", "01:30", "2024-11-03T01:30:00-04:00"},
+		{"America/New_York", "2024-11-03", "02:30", "2024-11-03T02:30:00-05:00"},
+		{"Europe/Berlin", "2024-10-27", "02:30", "2024-10-27T02:30:00+02:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.zone+" "+tt.date+" "+tt.clock, func(t *testing.T) {
+			useBusinessLocation(t, tt.zone)
+			got, err := BusinessTimeOn(tt.date, tt.clock)
+			if err != nil {
+				t.Fatalf("BusinessTimeOn: %v", err)
+			}
+			want, err := time.Parse(time.RFC3339, tt.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(want) {
+				t.Errorf("BusinessTimeOn(%s, %s) = %s, want %s", tt.date, tt.clock, got.Format(time.RFC3339), tt.want)
+			}
+		})
+	}
+}
+
+func TestBusinessTimeOnRejectsBadInput(t *testing.T) {
+	useBusinessLocation(t, "Asia/Seoul")
+
+	for _, in := range [][2]string{
+		{"2026-13-01", "09:00"},
+		{"05-01-2026", "09:00"},
+		{"2026-01-05", "24:00"},
+		{"2026-01-05", "9am"},
+	} {
+		if _, err := BusinessTimeOn(in[0], in[1]); err == nil {
+			t.Errorf("BusinessTimeOn(%s, %s) succeeded, want an error", in[0], in[1])
+		}
+	}
+}
+
+func TestInBusinessTimeOrNil(t *testing.T) {
+	loc := useBusinessLocation(t, "Asia/Seoul")
+
+	if got := InBusinessTimeOrNil(nil); got != nil {
+		t.Errorf("InBusinessTimeOrNil(nil) = %v, want nil", got)
+	}
+	at := time.Date(2026, 1, 5, 15, 0, 0, 0, time.UTC)
+	got := InBusinessTimeOrNil(&at)
+	if got == nil || got.Location() != loc || !got.Equal(at) {
+		t.Errorf("InBusinessTimeOrNil(%s) = %v, want the same instant in %s", at, got, loc)
+	}
+}
@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// HolidayEntry is one day read from an imported holiday calendar
+type HolidayEntry struct {
+	Date string // YYYY-MM-DD
+	Name string
+}
+
+// ParseHolidayCSV reads "date,name" rows with dates as YYYY-MM-DD. A header
+// row is skipped.
+func ParseHolidayCSV(r io.Reader) ([]HolidayEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var entries []HolidayEntry
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		date := strings.TrimSpace(record[0])
+		if _, err := time.Parse(businessDateLayout, date); err != nil {
+			if line == 1 {
+				continue // header
+			}
+			return nil, fmt.Errorf("line %d: invalid date %q, use YYYY-MM-DD", line, date)
+		}
+		name := ""
+		if len(record) > 1 {
+			name = strings.TrimSpace(record[1])
+		}
+		entries = append(entries, HolidayEntry{Date: date, Name: name})
+	}
+	return entries, nil
+}
+
+// icsText undoes the TEXT escaping of RFC 5545 3.3.11. Line breaks become
+// spaces since holiday names are single-line.
+var icsText = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, " ", `\N`, " ")
+
+// ParseHolidayICS reads the VEVENTs of an iCalendar file, such as a public
+// holiday calendar export. Events spanning several days yield one entry per
+// day. Timed events are placed on the business days they cover in the
+// business timezone.
+func ParseHolidayICS(r io.Reader) ([]HolidayEntry, error) {
+	// Unfold continuation lines (RFC 5545 3.1)
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var entries []HolidayEntry
+	inEvent := false
+	var summary string
+	var start, end icsValue
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		property, params, _ := strings.Cut(name, ";")
+
+		switch strings.ToUpper(property) {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				inEvent = true
+				summary, start, end = "", icsValue{}, icsValue{}
+			}
+		case "SUMMARY":
+			summary = strings.TrimSpace(icsText.Replace(value))
+		case "DTSTART":
+			start = icsValue{params: params, value: value}
+		case "DTEND":
+			end = icsValue{params: params, value: value}
+		case "END":
+			if !inEvent || !strings.EqualFold(value, "VEVENT") {
+				continue
+			}
+			inEvent = false
+			days, err := icsEventDays(start, end)
+			if err != nil {
+				return nil, fmt.Errorf("event %q: %w", summary, err)
+			}
+			for _, day := range days {
+				entries = append(entries, HolidayEntry{Date: day, Name: summary})
+			}
+		}
+	}
+	return entries, nil
+}
+
+// icsValue is a DTSTART or DTEND with its parameters, e.g. "TZID=Asia/Seoul"
+type icsValue struct {
+	params string
+	value  string
+}
+
+func (v icsValue) param(name string) string {
+	for _, p := range strings.Split(v.params, ";") {
+		if key, value, ok := strings.Cut(p, "="); ok && strings.EqualFold(key, name) {
+			return strings.Trim(value, `"`)
+		}
+	}
+	return ""
+}
+
+// parse reads a DATE or DATE-TIME value. Dates are returned as midnight UTC
+// with allDay set; times honour a trailing Z or TZID and are otherwise
+// floating, which is read in the business timezone.
+func (v icsValue) parse() (t time.Time, allDay bool, err error) {
+	if strings.EqualFold(v.param("VALUE"), "DATE") || len(v.value) == 8 {
+		t, err = time.Parse("20060102", v.value)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(v.value, "Z") {
+		t, err = time.Parse("20060102T150405Z", v.value)
+		return t, false, err
+	}
+	loc := BusinessLocation()
+	if tzid := v.param("TZID"); tzid != "" {
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, false, fmt.Errorf("unknown TZID %q", tzid)
+		}
+	}
+	t, err = time.ParseInLocation("20060102T150405", v.value, loc)
+	return t, false, err
+}
+
+// icsEventDays expands DTSTART/DTEND into dates. DTEND is exclusive; without
+// it the event covers its start day.
+func icsEventDays(start, end icsValue) ([]string, error) {
+	if start.value == "" {
+		return nil, errors.New("missing DTSTART")
+	}
+	first, allDay, err := start.parse()
+	if err != nil {
+		return nil, fmt.Errorf("invalid DTSTART %q", start.value)
+	}
+	last := first
+	if end.value != "" {
+		endAt, endAllDay, err := end.parse()
+		if err != nil || endAllDay != allDay {
+			return nil, fmt.Errorf("invalid DTEND %q", end.value)
+		}
+		if endAt.After(first) {
+			if allDay {
+				last = endAt.AddDate(0, 0, -1)
+			} else {
+				last = endAt.Add(-time.Nanosecond)
+			}
+		}
+	}
+
+	if !allDay {
+		// Walk the business days between the two instants
+		firstDay, _ := ParseBusinessDate(BusinessDate(first))
+		lastDay, _ := ParseBusinessDate(BusinessDate(last))
+		first, last = firstDay, lastDay
+	}
+
+	var days []string
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		days = append(days, d.Format(businessDateLayout))
+	}
+	return days, nil
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseHolidayICS(t *testing.T) {
+	useBusinessLocation(t, "Asia/Seoul")
+
+	event := func(lines ...string) string {
+		return "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	}
+
+	tests := []struct {
+		name string
+		ics  string
+		want []HolidayEntry
+	}{
+		{
+			name: "all-day event",
+			ics:  event("DTSTART;VALUE=DATE:20260301", "DTEND;VALUE=DATE:20260302", "SUMMARY:Independence Movement Day"),
+			want: []HolidayEntry{{"2026-03-01", "Independence Movement Day"}},
+		},
+		{
+			name: "multi-day event with exclusive end",
+			ics:  event("DTSTART;VALUE=DATE:20260216", "DTEND;VALUE=DATE:20260219", "SUMMARY:Seollal"),
+			want: []HolidayEntry{{"2026-02-16", "Seollal"}, {"2026-02-17", "Seollal"}, {"2026-02-18", "Seollal"}},
+		},
+		{
+			name: "all-day event without end",
+			ics:  event("DTSTART:20260505", "SUMMARY:Children's Day"),
+			want: []HolidayEntry{{"2026-05-05", "Children's Day"}},
+		},
+		{
+			name: "UTC time lands on the Korean date",
+			ics:  event("DTSTART:20260505T150000Z", "DTEND:20260506T150000Z", "SUMMARY:Company day"),
+			want: []HolidayEntry{{"2026-05-06", "Company day"}},
+		},
+		{
+			name: "TZID time is converted to business time",
+			ics:  event("DTSTART;TZID=America/Los_Angeles:20260505T090000", "DTEND;TZID=America/Los_Angeles:20260505T170000", "SUMMARY:Offsite"),
+			want: []HolidayEntry{{"2026-05-06", "Offsite"}},
+		},
+		{
+			name: "floating time is business time",
+			ics:  event("DTSTART:20260505T000000", "DTEND:20260506T000000", "SUMMARY:Floating"),
+			want: []HolidayEntry{{"2026-05-05", "Floating"}},
+		},
+		{
+			name: "summary escapes and folded lines",
+			ics:  event("DTSTART;VALUE=DATE:20261009", `SUMMARY:Hangul Day\, \;observed\; a\\b\nline`, " two"),
+			want: []HolidayEntry{{"2026-10-09", `Hangul Day, ;observed; a\b linetwo`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHolidayICS(strings.NewReader(tt.ics))
+			if err != nil {
+				t.Fatalf("ParseHolidayICS: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseHolidayICSRejectsBadEvents(t *testing.T) {
+	useBusinessLocation(t, "Asia/Seoul")
+
+	for _, lines := range []string{
+		"SUMMARY:No start",
+		"DTSTART:2026-05-05\r\nSUMMARY:Bad date",
+		"DTSTART;TZID=Mars/Olympus:20260505T090000\r\nSUMMARY:Bad zone",
+		"DTSTART;VALUE=DATE:20260505\r\nDTEND:20260506T000000Z\r\nSUMMARY:Mixed",
+	} {
+		ics := "BEGIN:VEVENT\r\n" + lines + "\r\nEND:VEVENT\r\n"
+		if _, err := ParseHolidayICS(strings.NewReader(ics)); err == nil {
+			t.Errorf("ParseHolidayICS(%q) succeeded, want an error", lines)
+		}
+	}
+}
+
+func TestParseHolidayCSV(t *testing.T) {
+	got, err := ParseHolidayCSV(strings.NewReader("date,name\n2026-01-01, New Year\n\n2026-03-01,\"Independence Movement Day\"\n"))
+	if err != nil {
+		t.Fatalf("ParseHolidayCSV: %v", err)
+	}
+	want := []HolidayEntry{{"2026-01-01", "New Year"}, {"2026-03-01", "Independence Movement Day"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseHolidayCSV(strings.NewReader("2026-01-01,New Year\n01/03/2026,Bad\n")); err == nil {
+		t.Error("ParseHolidayCSV accepted an invalid date")
+	}
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// QRImageOptions controls how a badge QR code is rendered
+type QRImageOptions struct {
+	Size   int    // output width/height in pixels
+	Level  string // error correction: "L", "M", "Q" or "H"
+	Margin int    // quiet zone in modules
+}
+
+func recoveryLevel(level string) (qrcode.RecoveryLevel, error) {
+	switch strings.ToUpper(level) {
+	case "L":
+		return qrcode.Low, nil
+	case "", "M":
+		return qrcode.Medium, nil
+	case "Q":
+		return qrcode.High, nil
+	case "H":
+		return qrcode.Highest, nil
+	}
+	return 0, fmt.Errorf("unknown error correction level %q", level)
+}
+
+// qrModules returns the QR module matrix including the requested margin
+func qrModules(content string, opts QRImageOptions) ([][]bool, error) {
+	level, err := recoveryLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, err
+	}
+	code.DisableBorder = true
+	bitmap := code.Bitmap()
+
+	total := len(bitmap) + 2*opts.Margin
+	modules := make([][]bool, total)
+	for y := range modules {
+		modules[y] = make([]bool, total)
+	}
+	for y, row := range bitmap {
+		copy(modules[y+opts.Margin][opts.Margin:], row)
+	}
+	return modules, nil
+}
+
+// RenderQRImage returns the QR code as an image of exactly opts.Size pixels
+func RenderQRImage(content string, opts QRImageOptions) (image.Image, error) {
+	modules, err := qrModules(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	total := len(modules)
+	if opts.Size < total {
+		return nil, fmt.Errorf("size must be at least %d pixels for this code", total)
+	}
+
+	img := image.NewGray(image.Rect(0, 0, opts.Size, opts.Size))
+	for y := 0; y < opts.Size; y++ {
+		for x := 0; x < opts.Size; x++ {
+			if modules[y*total/opts.Size][x*total/opts.Size] {
+				img.SetGray(x, y, color.Gray{Y: 0})
+			} else {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	return img, nil
+}
+
+// RenderQRPNG encodes the QR code as a PNG
+func RenderQRPNG(content string, opts QRImageOptions) ([]byte, error) {
+	img, err := RenderQRImage(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderQRSVG encodes the QR code as an SVG using one path for all dark modules
+func RenderQRSVG(content string, opts QRImageOptions) ([]byte, error) {
+	modules, err := qrModules(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	total := len(modules)
+	var path strings.Builder
+	for y, row := range modules {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, opts.Size, opts.Size, total, total)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/>`, total, total)
+	fmt.Fprintf(&buf, `<path d="%s" fill="#000"/>`, path.String())
+	buf.WriteString(`</svg>`)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const qrTokenVersion = "v1"
+
+var ErrMalformedQRToken = errors.New("malformed QR token")
+var ErrBadQRSignature = errors.New("invalid QR token signature")
+
+// QRTokenClaims is the decoded content of a signed QR badge token.
+type QRTokenClaims struct {
+	EmployeeID uint
+	IssuedAt   time.Time
+	Nonce      string
+}
+
+func qrSecret() []byte {
+	if secret := os.Getenv("QR_SIGNING_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	return []byte(os.Getenv("JWT_SECRET"))
+}
+
+func signQRPayload(payload string) string {
+	mac := hmac.New(sha256.New, qrSecret())
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateQRToken returns a signed badge token of the form
+// v1.<employee_id>.<issued_at_unix>.<nonce>.<signature>
+func GenerateQRToken(employeeID uint, issuedAt time.Time) (string, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	payload := fmt.Sprintf("%s.%d.%d.%s", qrTokenVersion, employeeID, issuedAt.Unix(), hex.EncodeToString(nonce))
+	return payload + "." + signQRPayload(payload), nil
+}
+
+// RandomQRID returns an unguessable value to replace an employee's legacy
+// QR ID when their codes are rotated or revoked, so the printed ID stops
+// matching anyone
+func RandomQRID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return "rotated-" + hex.EncodeToString(id), nil
+}
+
+// IsQRToken reports whether code looks like a signed token rather than a legacy QR ID
+func IsQRToken(code string) bool {
+	return strings.HasPrefix(code, qrTokenVersion+".") && strings.Count(code, ".") == 4
+}
+
+// ParseQRToken checks the signature of a token and returns its claims.
+// Expiry and revocation are checked by the caller.
+func ParseQRToken(token string) (*QRTokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 || parts[0] != qrTokenVersion {
+		return nil, ErrMalformedQRToken
+	}
+
+	payload := strings.Join(parts[:4], ".")
+	if !hmac.Equal([]byte(signQRPayload(payload)), []byte(parts[4])) {
+		return nil, ErrBadQRSignature
+	}
+
+	employeeID, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return nil, ErrMalformedQRToken
+	}
+	issuedAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, ErrMalformedQRToken
+	}
+
+	return &QRTokenClaims{
+		EmployeeID: uint(employeeID),
+		IssuedAt:   time.Unix(issuedAt, 0),
+		Nonce:      parts[3],
+	}, nil
+}
@@ -0,0 +1,44 @@
+package utils
+
+import "time"
+
+// roundSteps rounds value to a multiple of step: "up", "down" or, for any
+// other mode, to the nearest multiple (halves round up)
+func roundSteps(value, step int64, mode string) int64 {
+	if step <= 0 {
+		return value
+	}
+	rem := value % step
+	if rem == 0 {
+		return value
+	}
+	down := value - rem
+	switch mode {
+	case "up":
+		return down + step
+	case "down":
+		return down
+	}
+	if rem*2 >= step {
+		return down + step
+	}
+	return down
+}
+
+// RoundMinutes rounds a duration in minutes to a multiple of step minutes
+func RoundMinutes(minutes, step int, mode string) int {
+	return int(roundSteps(int64(minutes), int64(step), mode))
+}
+
+// RoundClock rounds t to a multiple of step minutes on the business-time
+// wall clock, so 15-minute steps land on :00/:15/:30/:45 locally
+func RoundClock(t time.Time, step int, mode string) time.Time {
+	if step <= 0 {
+		return t
+	}
+	local := t.In(BusinessLocation())
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	offset := int64(local.Sub(midnight))
+	rounded := roundSteps(offset, int64(step)*int64(time.Minute), mode)
+	return midnight.Add(time.Duration(rounded))
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// KioskCodePeriod is how long a kiosk code stays on screen before rotating
+const KioskCodePeriod = 30 * time.Second
+
+// KioskCodeCounter returns the time step a code for t belongs to
+func KioskCodeCounter(t time.Time) uint64 {
+	return uint64(t.Unix() / int64(KioskCodePeriod/time.Second))
+}
+
+// KioskCode computes the RFC 4226 style 8 digit code for a counter
+func KioskCode(secret []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%08d", value%100000000)
+}
+
+// VerifyKioskCode accepts the code for the current step or up to `window`
+// steps before it, to allow for the time it takes to scan and submit.
+func VerifyKioskCode(secret []byte, code string, now time.Time, window int) bool {
+	counter := KioskCodeCounter(now)
+	for i := 0; i <= window && uint64(i) <= counter; i++ {
+		if hmac.Equal([]byte(KioskCode(secret, counter-uint64(i))), []byte(code)) {
+			return true
+		}
+	}
+	return false
+}